@@ -6,11 +6,16 @@ import (
 	"os"
 	"path/filepath"
 
+	"wedding-invite/pkg/auth"
 	"wedding-invite/pkg/db"
 	"wedding-invite/pkg/handlers"
 	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/mail"
+	"wedding-invite/pkg/maps"
 	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
 	"wedding-invite/pkg/security"
+	"wedding-invite/pkg/storage"
 
 	"github.com/joho/godotenv"
 )
@@ -35,16 +40,57 @@ func main() {
 	}
 	defer db.Close()
 
+	// Load the RSVP deadline configuration
+	if err := models.InitializeDeadline(); err != nil {
+		log.Fatalf("Failed to initialize RSVP deadline: %v", err)
+	}
+
+	// Seed and load the menu options
+	if err := models.InitializeMealOptions(); err != nil {
+		log.Fatalf("Failed to initialize meal options: %v", err)
+	}
+	if err := models.InitializeCourseChoices(); err != nil {
+		log.Fatalf("Failed to initialize course choices: %v", err)
+	}
+
 	// Initialize security package
 	if err := security.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize security: %v", err)
 	}
 
+	// Load the couple's addresses, which may open the admin pages
+	if err := auth.InitializeAdmins(); err != nil {
+		log.Fatalf("Failed to initialize admin access: %v", err)
+	}
+
 	// Initialize internationalization
 	if err := i18n.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize language translations: %v", err)
 	}
 
+	// Initialize storage for uploaded files
+	if err := storage.Initialize(); err != nil {
+		log.Fatalf("Failed to initialize upload storage: %v", err)
+	}
+
+	// Initialize outgoing email for notifications and replies
+	if err := mail.Initialize(); err != nil {
+		log.Fatalf("Failed to initialize email: %v", err)
+	}
+
+	// Seed the photo gallery, which needs the upload storage
+	if err := models.InitializeGallery(); err != nil {
+		log.Fatalf("Failed to initialize gallery: %v", err)
+	}
+
+	// Initialize static maps and seed the travel page
+	if err := maps.Initialize(); err != nil {
+		log.Fatalf("Failed to initialize maps: %v", err)
+	}
+	if err := models.InitializeTravel(); err != nil {
+		log.Fatalf("Failed to initialize travel places: %v", err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -63,9 +109,54 @@ func main() {
 
 	// Protected routes
 	mux.Handle("/wedding", handlers.Wedding())
+	mux.Handle("/wedding/calendar.ics", handlers.HandleCalendar())
+	mux.Handle("/accommodation", handlers.HandleAccommodation())
+	mux.Handle("/registry", handlers.HandleRegistry())
+	mux.Handle("/songs", handlers.HandleSongs())
+	mux.Handle("/guestbook", handlers.HandleGuestbook())
+	mux.Handle("/photos", handlers.HandlePhotos())
+	mux.Handle("/photos/file", handlers.HandlePhotoFile())
+	mux.Handle("/gallery", handlers.HandleGallery())
+	mux.Handle("/gallery/file", handlers.HandleGalleryFile())
+	mux.Handle("/gallery/download", handlers.HandleGalleryDownload())
+	mux.Handle("/story", handlers.HandleStory())
+	mux.Handle("/faq", handlers.HandleFAQ())
+	mux.Handle("/questions", handlers.HandleQuestions())
+	mux.Handle("/travel", handlers.HandleTravel())
+	mux.Handle("/travel/map", handlers.HandleTravelMap())
 	mux.Handle("/rsvp", handlers.HandleRSVP())
 	mux.Handle("/rsvp/status", handlers.HandleRSVPStatus())
+	mux.Handle("/rsvp/seats", handlers.HandleSeatRequest())
 	mux.Handle("/admin/guests", handlers.HandleAdminGuests())
+	mux.Handle("/admin/events", handlers.HandleAdminEvents())
+	mux.Handle("/admin/invitations", handlers.HandleAdminInvitations())
+	mux.Handle("/admin/rsvp", handlers.HandleAdminRSVPDeadline())
+	mux.Handle("/admin/seats", handlers.HandleAdminSeatRequests())
+	mux.Handle("/admin/venue", handlers.HandleAdminVenue())
+	mux.Handle("/admin/revisions", handlers.HandleAdminRevisions())
+	mux.Handle("/admin/catering", handlers.HandleAdminCatering())
+	mux.Handle("/admin/accessibility", handlers.HandleAdminAccessibility())
+	mux.Handle("/admin/menu", handlers.HandleAdminMenu())
+	mux.Handle("/admin/menu/courses", handlers.HandleAdminCourseChoices())
+	mux.Handle("/admin/shuttles", handlers.HandleAdminShuttles())
+	mux.Handle("/admin/shuttles/manifest", handlers.HandleShuttleManifest())
+	mux.Handle("/admin/hotels", handlers.HandleAdminHotels())
+	mux.Handle("/admin/hotels/rooming", handlers.HandleRoomingList())
+	mux.Handle("/admin/seating", handlers.HandleAdminSeating())
+	mux.Handle("/admin/seating/assign", handlers.HandleSeatingAssign())
+	mux.Handle("/admin/seating/print", handlers.HandleSeatingPrint())
+	mux.Handle("/admin/registry", handlers.HandleAdminRegistry())
+	mux.Handle("/admin/songs", handlers.HandleAdminSongs())
+	mux.Handle("/admin/songs/export", handlers.HandleSongExport())
+	mux.Handle("/admin/guestbook", handlers.HandleAdminGuestbook())
+	mux.Handle("/admin/guestbook/export", handlers.HandleGuestbookExport())
+	mux.Handle("/admin/photos", handlers.HandleAdminPhotos())
+	mux.Handle("/admin/photos/file", handlers.HandleAdminPhotoFile())
+	mux.Handle("/admin/gallery", handlers.HandleAdminGallery())
+	mux.Handle("/admin/story", handlers.HandleAdminStory())
+	mux.Handle("/admin/faq", handlers.HandleAdminFAQ())
+	mux.Handle("/admin/questions", handlers.HandleAdminQuestions())
+	mux.Handle("/admin/travel", handlers.HandleAdminTravel())
 	
 	// HTMX endpoints for the RSVP flow
 	mux.Handle("/rsvp/submit", handlers.HandleSubmitRSVP())
@@ -4,6 +4,7 @@ go 1.24.1
 
 require (
 	github.com/a-h/templ v0.3.857
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/joho/godotenv v1.5.1
 	github.com/mattn/go-sqlite3 v1.14.24
 )
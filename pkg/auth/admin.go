@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"os"
+	"strings"
+)
+
+// adminEmails are the invitations allowed to open the admin pages
+var adminEmails = map[string]bool{}
+
+// InitializeAdmins reads the couple's addresses from ADMIN_EMAILS, the same list that is
+// notified by email. Without it nobody can open the admin pages.
+func InitializeAdmins() error {
+	for _, address := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if address = strings.TrimSpace(address); address == "" {
+			continue
+		}
+		parsed, err := netmail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid address %q in ADMIN_EMAILS: %w", address, err)
+		}
+		adminEmails[strings.ToLower(parsed.Address)] = true
+	}
+
+	if len(adminEmails) == 0 {
+		log.Printf("ADMIN_EMAILS not set, the admin pages are closed to everyone")
+	}
+	return nil
+}
+
+// IsAdmin reports whether the invitation may open the admin pages
+func IsAdmin(email string) bool {
+	return adminEmails[strings.ToLower(strings.TrimSpace(email))]
+}
@@ -2,12 +2,13 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 var DB *sql.DB
@@ -73,11 +74,369 @@ func setupSchema() error {
 			expires_at TIMESTAMP,
 			ip_address_hash TEXT
 		);
+
+		CREATE TABLE IF NOT EXISTS invitation_events (
+			invitation_email TEXT REFERENCES invitations(email),
+			event_key TEXT NOT NULL,
+			PRIMARY KEY (invitation_email, event_key)
+		);
+
+		CREATE TABLE IF NOT EXISTS rsvp_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			snapshot TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_allergens (
+			guest_id INTEGER REFERENCES guests(id),
+			allergen TEXT NOT NULL,
+			PRIMARY KEY (guest_id, allergen)
+		);
+
+		CREATE TABLE IF NOT EXISTS meal_options (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			key TEXT UNIQUE NOT NULL,
+			label_en TEXT NOT NULL,
+			label_ro TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			children_only BOOLEAN NOT NULL DEFAULT FALSE
+		);
+
+		CREATE TABLE IF NOT EXISTS course_choices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course TEXT NOT NULL,
+			label_en TEXT NOT NULL,
+			label_ro TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			active BOOLEAN NOT NULL DEFAULT TRUE
+		);
+
+		CREATE TABLE IF NOT EXISTS course_choice_meal_types (
+			choice_id INTEGER REFERENCES course_choices(id),
+			meal_type TEXT NOT NULL,
+			PRIMARY KEY (choice_id, meal_type)
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_courses (
+			guest_id INTEGER REFERENCES guests(id),
+			course TEXT NOT NULL,
+			choice_id INTEGER REFERENCES course_choices(id),
+			PRIMARY KEY (guest_id, course)
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_accessibility (
+			guest_id INTEGER REFERENCES guests(id),
+			need TEXT NOT NULL,
+			PRIMARY KEY (guest_id, need)
+		);
+
+		CREATE TABLE IF NOT EXISTS shuttle_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			label_en TEXT NOT NULL,
+			label_ro TEXT NOT NULL,
+			departs_at TIMESTAMP NOT NULL,
+			capacity INTEGER NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE
+		);
+
+		CREATE TABLE IF NOT EXISTS shuttle_bookings (
+			run_id INTEGER REFERENCES shuttle_runs(id),
+			guest_id INTEGER REFERENCES guests(id),
+			PRIMARY KEY (run_id, guest_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS hotels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			address TEXT NOT NULL DEFAULT '',
+			website TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS room_blocks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			hotel_id INTEGER REFERENCES hotels(id),
+			room_type_en TEXT NOT NULL,
+			room_type_ro TEXT NOT NULL,
+			rooms INTEGER NOT NULL,
+			check_in DATE NOT NULL,
+			check_out DATE NOT NULL,
+			nightly_rate INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS room_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			block_id INTEGER REFERENCES room_blocks(id),
+			rooms INTEGER NOT NULL,
+			check_in DATE NOT NULL,
+			check_out DATE NOT NULL,
+			notes TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			decided_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS seating_tables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			shape TEXT NOT NULL DEFAULT 'round',
+			capacity INTEGER NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS seat_assignments (
+			guest_id INTEGER PRIMARY KEY REFERENCES guests(id),
+			table_id INTEGER REFERENCES seating_tables(id),
+			seat INTEGER NOT NULL,
+			UNIQUE (table_id, seat)
+		);
+
+		CREATE TABLE IF NOT EXISTS seating_separations (
+			guest_id INTEGER REFERENCES guests(id),
+			other_guest_id INTEGER REFERENCES guests(id),
+			PRIMARY KEY (guest_id, other_guest_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS registry_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			title_en TEXT NOT NULL,
+			title_ro TEXT NOT NULL,
+			description_en TEXT NOT NULL DEFAULT '',
+			description_ro TEXT NOT NULL DEFAULT '',
+			target_amount INTEGER NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE
+		);
+
+		CREATE TABLE IF NOT EXISTS registry_pledges (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id INTEGER REFERENCES registry_items(id),
+			invitation_email TEXT REFERENCES invitations(email),
+			amount INTEGER NOT NULL,
+			message TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			thanked_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS song_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			title TEXT NOT NULL,
+			artist TEXT NOT NULL,
+			dedication TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS song_votes (
+			song_id INTEGER REFERENCES song_requests(id),
+			invitation_email TEXT REFERENCES invitations(email),
+			PRIMARY KEY (song_id, invitation_email)
+		);
+
+		CREATE TABLE IF NOT EXISTS guestbook_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			author_name TEXT NOT NULL,
+			message TEXT NOT NULL,
+			language TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_photos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			file_key TEXT NOT NULL,
+			thumbnail_key TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS gallery_albums (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			slug TEXT NOT NULL UNIQUE,
+			title_en TEXT NOT NULL,
+			title_ro TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS gallery_photos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			album_id INTEGER REFERENCES gallery_albums(id),
+			file_key TEXT NOT NULL,
+			caption_en TEXT NOT NULL DEFAULT '',
+			caption_ro TEXT NOT NULL DEFAULT '',
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			variant_widths TEXT NOT NULL DEFAULT '',
+			sort_order INTEGER NOT NULL DEFAULT 0,
+			featured BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS story_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			happened_on TEXT NOT NULL,
+			title_en TEXT NOT NULL,
+			title_ro TEXT NOT NULL,
+			body_en TEXT NOT NULL DEFAULT '',
+			body_ro TEXT NOT NULL DEFAULT '',
+			photo_id INTEGER REFERENCES gallery_photos(id)
+		);
+
+		CREATE TABLE IF NOT EXISTS faq_categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title_en TEXT NOT NULL,
+			title_ro TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS faq_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			category_id INTEGER NOT NULL REFERENCES faq_categories(id),
+			question_en TEXT NOT NULL,
+			question_ro TEXT NOT NULL,
+			answer_en TEXT NOT NULL,
+			answer_ro TEXT NOT NULL,
+			sort_order INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS faq_entry_events (
+			entry_id INTEGER NOT NULL REFERENCES faq_entries(id),
+			event_key TEXT NOT NULL,
+			PRIMARY KEY (entry_id, event_key)
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_questions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT NOT NULL REFERENCES invitations(email),
+			subject TEXT NOT NULL,
+			message TEXT NOT NULL,
+			language TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'unread',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS guest_question_replies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL REFERENCES guest_questions(id),
+			body TEXT NOT NULL,
+			emailed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS travel_places (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			name TEXT NOT NULL,
+			address TEXT NOT NULL DEFAULT '',
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			notes_en TEXT NOT NULL DEFAULT '',
+			notes_ro TEXT NOT NULL DEFAULT '',
+			website TEXT NOT NULL DEFAULT '',
+			sort_order INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS seat_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			invitation_email TEXT REFERENCES invitations(email),
+			seats INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			response TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		);
 	`)
+	if err != nil {
+		return err
+	}
 
+	// Add columns introduced after the tables were first created
+	for _, c := range columnMigrations {
+		if err := addColumnIfMissing(c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+
+	return nil
+}
+
+// columnMigrations lists columns added to existing tables, in the order they were introduced
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"invitations", "rsvp_extended_until", "TIMESTAMP"},
+	{"invitations", "rsvp_version", "INTEGER NOT NULL DEFAULT 0"},
+	{"guests", "age_category", "TEXT NOT NULL DEFAULT 'adult'"},
+	{"guests", "age", "INTEGER"},
+	{"guests", "named", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"invitations", "plus_ones", "INTEGER NOT NULL DEFAULT 0"},
+	{"guests", "venue_status", "TEXT NOT NULL DEFAULT ''"},
+	{"guests", "waitlisted_at", "TIMESTAMP"},
+	{"guests", "promoted_at", "TIMESTAMP"},
+	{"guests", "accessibility_notes", "TEXT"},
+	{"hotels", "latitude", "REAL"},
+	{"hotels", "longitude", "REAL"},
+	{"hotels", "notes_en", "TEXT NOT NULL DEFAULT ''"},
+	{"hotels", "notes_ro", "TEXT NOT NULL DEFAULT ''"},
+	{"invitations", "language", "TEXT NOT NULL DEFAULT 'ro'"},
+}
+
+// addColumnIfMissing adds a column to a table unless it already exists,
+// since SQLite has no ADD COLUMN IF NOT EXISTS
+func addColumnIfMissing(table, column, definition string) error {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    bool
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
+// IsUniqueViolation reports whether err was caused by a UNIQUE constraint
+func IsUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
 func Close() {
 	if DB != nil {
 		DB.Close()
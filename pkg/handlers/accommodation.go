@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleAccommodation lists the hotel room blocks and lets the invitee request rooms
+func HandleAccommodation() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			blockID, blockErr := strconv.ParseInt(r.Form.Get("block_id"), 10, 64)
+			rooms, roomsErr := strconv.Atoi(r.Form.Get("rooms"))
+			checkIn, checkInErr := models.ParseDate(r.Form.Get("check_in"))
+			checkOut, checkOutErr := models.ParseDate(r.Form.Get("check_out"))
+			if blockErr != nil || roomsErr != nil || checkInErr != nil || checkOutErr != nil {
+				http.Redirect(w, r, "/accommodation?notice=invalid", http.StatusSeeOther)
+				return
+			}
+
+			err := models.CreateRoomRequest(email, blockID, rooms, checkIn, checkOut, r.Form.Get("notes"))
+			if err != nil {
+				log.Printf("Error creating room request for %s: %v", email, err)
+				http.Redirect(w, r, "/accommodation?notice=invalid", http.StatusSeeOther)
+				return
+			}
+
+			http.Redirect(w, r, "/accommodation?notice=requested", http.StatusSeeOther)
+			return
+		}
+
+		hotels, err := models.GetHotels()
+		if err != nil {
+			log.Printf("Error fetching hotels: %v", err)
+			http.Error(w, "Failed to load accommodation data", http.StatusInternalServerError)
+			return
+		}
+
+		requests, err := models.GetInvitationRoomRequests(email)
+		if err != nil {
+			log.Printf("Error fetching room requests for %s: %v", email, err)
+			http.Error(w, "Failed to load accommodation data", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "requested", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Accommodation(email, hotels, requests, notice, r).Render(r.Context(), w)
+	}))
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"wedding-invite/pkg/middleware"
 	"wedding-invite/pkg/models"
@@ -11,7 +18,25 @@ import (
 
 // HandleAdminGuests displays all guests in the database
 func HandleAdminGuests() http.Handler {
-	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Update whether infants count towards each invitation's seat limit
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			exempt := r.Form.Get("infants_exempt") == "true"
+			if err := models.SetInfantsExemptFromLimit(exempt); err != nil {
+				log.Printf("Error updating seat limit setting: %v", err)
+				http.Error(w, "Failed to update settings", http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/guests?success=true", http.StatusSeeOther)
+			return
+		}
+
 		// Get all guests
 		guests, err := models.GetAllGuests()
 		if err != nil {
@@ -20,7 +45,1440 @@ func HandleAdminGuests() http.Handler {
 			return
 		}
 
+		infantsExempt, err := models.AreInfantsExemptFromLimit()
+		if err != nil {
+			log.Printf("Error fetching seat limit setting: %v", err)
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Settings have been updated."
+		}
+
 		// Render admin guests page
-		templates.AdminGuests(guests, r).Render(r.Context(), w)
+		templates.AdminGuests(guests, infantsExempt, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminEvents shows and bulk-edits which events each invitation may attend
+func HandleAdminEvents() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			emails := r.Form["emails[]"]
+			eventKeys := r.Form["events[]"]
+			if len(emails) == 0 || len(eventKeys) == 0 {
+				http.Error(w, "Select at least one invitation and one event", http.StatusBadRequest)
+				return
+			}
+
+			for _, email := range emails {
+				if err := models.SetInvitationEvents(email, eventKeys); err != nil {
+					log.Printf("Error updating events for invitation %s: %v", email, err)
+					http.Error(w, "Failed to update invitation events", http.StatusBadRequest)
+					return
+				}
+			}
+
+			http.Redirect(w, r, "/admin/events?success=true", http.StatusSeeOther)
+			return
+		}
+
+		invitations, err := models.GetAllInvitationEvents()
+		if err != nil {
+			log.Printf("Error fetching invitation events: %v", err)
+			http.Error(w, "Failed to load invitation data", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Event access has been updated."
+		}
+
+		templates.AdminEvents(invitations, models.Events, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminRSVPDeadline manages per-invitation RSVP extensions and reopening RSVPs for everyone
+func HandleAdminRSVPDeadline() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Form.Get("action") {
+			case "reopen":
+				if err := models.SetRSVPReopened(r.Form.Get("reopened") == "true"); err != nil {
+					log.Printf("Error updating RSVP reopened setting: %v", err)
+					http.Error(w, "Failed to update RSVP setting", http.StatusInternalServerError)
+					return
+				}
+			case "extend":
+				until, err := models.ParseDeadline(r.Form.Get("until"), models.RSVPLocation())
+				if err != nil {
+					http.Error(w, "Invalid extension date", http.StatusBadRequest)
+					return
+				}
+				if err := models.SetRSVPExtension(r.Form.Get("email"), until); err != nil {
+					log.Printf("Error extending RSVP deadline: %v", err)
+					http.Error(w, "Failed to extend RSVP deadline", http.StatusBadRequest)
+					return
+				}
+			case "clear":
+				if err := models.SetRSVPExtension(r.Form.Get("email"), time.Time{}); err != nil {
+					log.Printf("Error clearing RSVP extension: %v", err)
+					http.Error(w, "Failed to clear RSVP extension", http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/rsvp?success=true", http.StatusSeeOther)
+			return
+		}
+
+		reopened, err := models.IsRSVPReopened()
+		if err != nil {
+			log.Printf("Error fetching RSVP reopened setting: %v", err)
+			http.Error(w, "Failed to load RSVP settings", http.StatusInternalServerError)
+			return
+		}
+
+		invitations, err := models.GetInvitationExtensions()
+		if err != nil {
+			log.Printf("Error fetching RSVP extensions: %v", err)
+			http.Error(w, "Failed to load invitation data", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "RSVP deadline settings have been updated."
+		}
+
+		templates.AdminRSVPDeadline(models.RSVPDeadline(), reopened, invitations, successMsg, r).
+			Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminInvitations manages the guests each invitation names and its plus-one slots
+func HandleAdminInvitations() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			email := r.Form.Get("email")
+
+			switch r.Form.Get("action") {
+			case "add_guest":
+				if _, err := models.AddNamedGuest(email, r.Form.Get("name")); err != nil {
+					log.Printf("Error adding named guest to %s: %v", email, err)
+					http.Error(w, "Failed to add guest", http.StatusBadRequest)
+					return
+				}
+			case "remove_guest":
+				guestID, err := strconv.ParseInt(r.Form.Get("guest_id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid guest", http.StatusBadRequest)
+					return
+				}
+				if err := models.RemoveNamedGuest(guestID, email); err != nil {
+					log.Printf("Error removing named guest %d from %s: %v", guestID, email, err)
+					http.Error(w, "Failed to remove guest", http.StatusBadRequest)
+					return
+				}
+				// The guest's place at the venue may go to the waitlist
+				if err := allocateVenuePlaces(); err != nil {
+					log.Printf("Error allocating venue places: %v", err)
+					http.Error(w, "Guest removed, but failed to allocate venue places", http.StatusInternalServerError)
+					return
+				}
+			case "plus_ones":
+				plusOnes, err := strconv.Atoi(r.Form.Get("plus_ones"))
+				if err != nil {
+					http.Error(w, "Invalid number of plus-ones", http.StatusBadRequest)
+					return
+				}
+				if err := models.SetPlusOnes(email, plusOnes); err != nil {
+					log.Printf("Error setting plus-ones for %s: %v", email, err)
+					http.Error(w, "Failed to update plus-ones", http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/invitations?success=true", http.StatusSeeOther)
+			return
+		}
+
+		allowances, err := models.GetInvitationAllowances()
+		if err != nil {
+			log.Printf("Error fetching invitation allowances: %v", err)
+			http.Error(w, "Failed to load invitation data", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "The invitation has been updated."
+		}
+
+		templates.AdminInvitations(allowances, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminSeatRequests is the approval queue for invitees asking for extra seats
+func HandleAdminSeatRequests() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid seat request", http.StatusBadRequest)
+				return
+			}
+
+			response := r.Form.Get("response")
+
+			switch r.Form.Get("action") {
+			case "approve":
+				if err := models.ApproveSeatRequest(id, response); err != nil {
+					log.Printf("Error approving seat request %d: %v", id, err)
+					http.Error(w, "Failed to approve seat request", http.StatusBadRequest)
+					return
+				}
+			case "reject":
+				if err := models.RejectSeatRequest(id, response); err != nil {
+					log.Printf("Error rejecting seat request %d: %v", id, err)
+					http.Error(w, "Failed to reject seat request", http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			if err := emailSeatRequestAnswer(id); err != nil {
+				// The invitee still sees the answer on their RSVP page
+				log.Printf("Error emailing answer to seat request %d: %v", id, err)
+				http.Redirect(w, r, "/admin/seats?success=unsent", http.StatusSeeOther)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/seats?success=true", http.StatusSeeOther)
+			return
+		}
+
+		requests, err := models.GetSeatRequests()
+		if err != nil {
+			log.Printf("Error fetching seat requests: %v", err)
+			http.Error(w, "Failed to load seat requests", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		switch r.URL.Query().Get("success") {
+		case "true":
+			successMsg = "The seat request has been answered and the invitee emailed."
+		case "unsent":
+			successMsg = "The seat request has been answered, but the email could not be sent. The invitee will still see the answer on their RSVP page."
+		}
+
+		templates.AdminSeatRequests(requests, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminVenue manages the venue's capacity and shows its waitlist and the guests promoted from it
+func HandleAdminVenue() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			capacity, err := strconv.Atoi(r.Form.Get("capacity"))
+			if err != nil {
+				http.Error(w, "Invalid capacity", http.StatusBadRequest)
+				return
+			}
+			if err := models.SetVenueCapacity(capacity); err != nil {
+				log.Printf("Error setting venue capacity: %v", err)
+				http.Error(w, "Failed to update venue capacity", http.StatusBadRequest)
+				return
+			}
+
+			// A larger venue makes room for the waitlist
+			if err := allocateVenuePlaces(); err != nil {
+				log.Printf("Error allocating venue places: %v", err)
+				http.Error(w, "Capacity saved, but failed to allocate venue places", http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/venue?success=true", http.StatusSeeOther)
+			return
+		}
+
+		overview, err := models.GetVenueOverview()
+		if err != nil {
+			log.Printf("Error fetching venue overview: %v", err)
+			http.Error(w, "Failed to load venue data", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "The venue capacity has been updated."
+		}
+
+		templates.AdminVenue(*overview, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminRevisions shows the RSVP history of all invitations, or the timeline of one
+func HandleAdminRevisions() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		email := r.URL.Query().Get("email")
+		if email == "" {
+			summaries, err := models.GetAllInvitationRevisions()
+			if err != nil {
+				log.Printf("Error fetching RSVP revisions: %v", err)
+				http.Error(w, "Failed to load RSVP history", http.StatusInternalServerError)
+				return
+			}
+
+			templates.AdminRevisions(summaries, r).Render(r.Context(), w)
+			return
+		}
+
+		timeline, err := models.GetRSVPTimeline(email)
+		if err != nil {
+			log.Printf("Error fetching RSVP timeline for %s: %v", email, err)
+			http.Error(w, "Failed to load RSVP history", http.StatusInternalServerError)
+			return
+		}
+
+		templates.AdminRevisionTimeline(email, timeline, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminCatering shows meal and allergen counts for the caterer
+func HandleAdminCatering() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		guests, err := models.GetAllGuests()
+		if err != nil {
+			log.Printf("Error fetching all guests: %v", err)
+			http.Error(w, "Failed to load guest data", http.StatusInternalServerError)
+			return
+		}
+
+		// Include retired options, guests may still have them selected
+		mealOptions, err := models.GetAllMealOptions()
+		if err != nil {
+			log.Printf("Error fetching meal options: %v", err)
+			http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+			return
+		}
+
+		courseChoices, err := models.GetAllCourseChoices()
+		if err != nil {
+			log.Printf("Error fetching course choices: %v", err)
+			http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+			return
+		}
+
+		report := models.BuildCateringReport(guests)
+		templates.AdminCatering(report, mealOptions, courseChoices, models.Allergens, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminAccessibility shows the guests who need assistance, for the venue coordinator
+func HandleAdminAccessibility() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := models.GetAccessibilityReport()
+		if err != nil {
+			log.Printf("Error building accessibility report: %v", err)
+			http.Error(w, "Failed to load accessibility report", http.StatusInternalServerError)
+			return
+		}
+
+		templates.AdminAccessibility(report, models.AccessibilityNeeds, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminShuttles lets admins add, reschedule, resize and retire shuttle runs
+func HandleAdminShuttles() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			departsAt, err := models.ParseDeadline(r.Form.Get("departs_at"), models.RSVPLocation())
+			if err != nil {
+				http.Error(w, "Invalid departure time", http.StatusBadRequest)
+				return
+			}
+			capacity, err := strconv.Atoi(r.Form.Get("capacity"))
+			if err != nil {
+				http.Error(w, "Invalid capacity", http.StatusBadRequest)
+				return
+			}
+			labelEN := r.Form.Get("label_en")
+			labelRO := r.Form.Get("label_ro")
+
+			if idStr := r.Form.Get("id"); idStr != "" {
+				id, err := strconv.ParseInt(idStr, 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid shuttle run", http.StatusBadRequest)
+					return
+				}
+
+				active := r.Form.Get("active") == "true"
+				err = models.UpdateShuttleRun(id, labelEN, labelRO, departsAt, capacity, active)
+				if err != nil {
+					log.Printf("Error updating shuttle run %d: %v", id, err)
+					http.Error(w, "Failed to update shuttle run", http.StatusBadRequest)
+					return
+				}
+			} else {
+				err = models.CreateShuttleRun(labelEN, labelRO, departsAt, capacity)
+				if err != nil {
+					log.Printf("Error creating shuttle run: %v", err)
+					http.Error(w, "Failed to create shuttle run", http.StatusBadRequest)
+					return
+				}
+			}
+
+			http.Redirect(w, r, "/admin/shuttles?success=true", http.StatusSeeOther)
+			return
+		}
+
+		runs, err := models.GetAllShuttleRuns()
+		if err != nil {
+			log.Printf("Error fetching shuttle runs: %v", err)
+			http.Error(w, "Failed to load shuttle runs", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "The shuttle runs have been updated."
+		}
+
+		templates.AdminShuttles(runs, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminHotels manages hotels, their room blocks and the room requests of invitees
+func HandleAdminHotels() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Form.Get("action") {
+			case "hotel":
+				name := r.Form.Get("name")
+				address := r.Form.Get("address")
+				website := r.Form.Get("website")
+				notesEN := r.Form.Get("notes_en")
+				notesRO := r.Form.Get("notes_ro")
+				latitude, longitude, err := models.ParseOptionalCoordinates(r.Form.Get("latitude"), r.Form.Get("longitude"))
+				if err != nil {
+					http.Error(w, "Invalid coordinates", http.StatusBadRequest)
+					return
+				}
+
+				if idStr := r.Form.Get("id"); idStr != "" {
+					id, parseErr := strconv.ParseInt(idStr, 10, 64)
+					if parseErr != nil {
+						http.Error(w, "Invalid hotel", http.StatusBadRequest)
+						return
+					}
+					err = models.UpdateHotel(id, name, address, website, latitude, longitude, notesEN, notesRO)
+				} else {
+					err = models.CreateHotel(name, address, website, latitude, longitude, notesEN, notesRO)
+				}
+				if err != nil {
+					log.Printf("Error saving hotel: %v", err)
+					http.Error(w, "Failed to save hotel: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			case "block":
+				rooms, err := strconv.Atoi(r.Form.Get("rooms"))
+				if err != nil {
+					http.Error(w, "Invalid number of rooms", http.StatusBadRequest)
+					return
+				}
+				rate, err := strconv.Atoi(r.Form.Get("nightly_rate"))
+				if err != nil {
+					http.Error(w, "Invalid rate", http.StatusBadRequest)
+					return
+				}
+				checkIn, err := models.ParseDate(r.Form.Get("check_in"))
+				if err != nil {
+					http.Error(w, "Invalid check-in date", http.StatusBadRequest)
+					return
+				}
+				checkOut, err := models.ParseDate(r.Form.Get("check_out"))
+				if err != nil {
+					http.Error(w, "Invalid check-out date", http.StatusBadRequest)
+					return
+				}
+				roomTypeEN := r.Form.Get("room_type_en")
+				roomTypeRO := r.Form.Get("room_type_ro")
+
+				if idStr := r.Form.Get("id"); idStr != "" {
+					id, parseErr := strconv.ParseInt(idStr, 10, 64)
+					if parseErr != nil {
+						http.Error(w, "Invalid room block", http.StatusBadRequest)
+						return
+					}
+					err = models.UpdateRoomBlock(id, roomTypeEN, roomTypeRO, rooms, checkIn, checkOut, rate)
+				} else {
+					hotelID, parseErr := strconv.ParseInt(r.Form.Get("hotel_id"), 10, 64)
+					if parseErr != nil {
+						http.Error(w, "Invalid hotel", http.StatusBadRequest)
+						return
+					}
+					err = models.CreateRoomBlock(hotelID, roomTypeEN, roomTypeRO, rooms, checkIn, checkOut, rate)
+				}
+				if err != nil {
+					log.Printf("Error saving room block: %v", err)
+					http.Error(w, "Failed to save room block", http.StatusBadRequest)
+					return
+				}
+			case "confirm", "decline":
+				id, err := strconv.ParseInt(r.Form.Get("request_id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid room request", http.StatusBadRequest)
+					return
+				}
+				if r.Form.Get("action") == "confirm" {
+					err = models.ConfirmRoomRequest(id)
+				} else {
+					err = models.DeclineRoomRequest(id)
+				}
+				if err != nil {
+					log.Printf("Error answering room request %d: %v", id, err)
+					http.Error(w, "Failed to answer room request", http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/hotels?success=true", http.StatusSeeOther)
+			return
+		}
+
+		hotels, err := models.GetHotels()
+		if err != nil {
+			log.Printf("Error fetching hotels: %v", err)
+			http.Error(w, "Failed to load hotels", http.StatusInternalServerError)
+			return
+		}
+
+		requests, err := models.GetRoomRequests()
+		if err != nil {
+			log.Printf("Error fetching room requests: %v", err)
+			http.Error(w, "Failed to load room requests", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Accommodation has been updated."
+		}
+
+		templates.AdminHotels(hotels, requests, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminSeating manages the reception tables, who is kept apart and the auto-seating draft
+func HandleAdminSeating() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			redirect := "/admin/seating?success=true"
+			switch r.Form.Get("action") {
+			case "table":
+				capacity, err := strconv.Atoi(r.Form.Get("capacity"))
+				if err != nil {
+					http.Error(w, "Invalid capacity", http.StatusBadRequest)
+					return
+				}
+				name := r.Form.Get("name")
+				shape := r.Form.Get("shape")
+
+				if idStr := r.Form.Get("id"); idStr != "" {
+					id, parseErr := strconv.ParseInt(idStr, 10, 64)
+					if parseErr != nil {
+						http.Error(w, "Invalid table", http.StatusBadRequest)
+						return
+					}
+					err = models.UpdateSeatingTable(id, name, shape, capacity)
+				} else {
+					err = models.CreateSeatingTable(name, shape, capacity)
+				}
+				if err != nil {
+					log.Printf("Error saving table: %v", err)
+					http.Error(w, "Failed to save table", http.StatusBadRequest)
+					return
+				}
+			case "delete_table":
+				id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid table", http.StatusBadRequest)
+					return
+				}
+				if err := models.DeleteSeatingTable(id); err != nil {
+					log.Printf("Error deleting table %d: %v", id, err)
+					http.Error(w, "Failed to delete table", http.StatusInternalServerError)
+					return
+				}
+			case "separate", "unseparate":
+				guestID, err := strconv.ParseInt(r.Form.Get("guest_id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid guest", http.StatusBadRequest)
+					return
+				}
+				otherGuestID, err := strconv.ParseInt(r.Form.Get("other_guest_id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid guest", http.StatusBadRequest)
+					return
+				}
+				if r.Form.Get("action") == "separate" {
+					err = models.AddSeatingSeparation(guestID, otherGuestID)
+				} else {
+					err = models.RemoveSeatingSeparation(guestID, otherGuestID)
+				}
+				if err != nil {
+					log.Printf("Error updating separation of guests %d and %d: %v", guestID, otherGuestID, err)
+					http.Error(w, "Failed to update the guests kept apart", http.StatusBadRequest)
+					return
+				}
+			case "auto":
+				seated, err := models.AutoSeat(r.Form.Get("replace") == "true")
+				if err != nil {
+					log.Printf("Error auto-seating guests: %v", err)
+					http.Error(w, "Failed to draft seats", http.StatusInternalServerError)
+					return
+				}
+				redirect = "/admin/seating?seated=" + strconv.Itoa(seated)
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, redirect, http.StatusSeeOther)
+			return
+		}
+
+		plan, err := models.GetSeatingPlan()
+		if err != nil {
+			log.Printf("Error fetching seating plan: %v", err)
+			http.Error(w, "Failed to load seating plan", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Seating plan has been updated."
+		}
+		if seated := r.URL.Query().Get("seated"); seated != "" {
+			successMsg = "Auto-seating placed " + seated + " guests."
+		}
+
+		templates.AdminSeating(plan, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleSeatingAssign moves guests dropped on a table and re-renders the seating board
+func HandleSeatingAssign() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		tableID, err := strconv.ParseInt(r.Form.Get("table_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid table", http.StatusBadRequest)
+			return
+		}
+
+		var guestIDs []int64
+		for _, idStr := range strings.Split(r.Form.Get("guest_ids"), ",") {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid guest", http.StatusBadRequest)
+				return
+			}
+			guestIDs = append(guestIDs, id)
+		}
+
+		// The board is re-rendered either way, so a refused move is reported on it
+		errorMsg := ""
+		if err := models.AssignSeats(guestIDs, tableID); err != nil {
+			switch {
+			case errors.Is(err, models.ErrTableFull):
+				errorMsg = "That table does not have enough free seats."
+			case errors.Is(err, models.ErrSeparatedGuests):
+				errorMsg = "Those guests are kept apart from someone at that table."
+			default:
+				log.Printf("Error assigning guests %v to table %d: %v", guestIDs, tableID, err)
+				errorMsg = "Failed to move the guests."
+			}
+		}
+
+		plan, err := models.GetSeatingPlan()
+		if err != nil {
+			log.Printf("Error fetching seating plan: %v", err)
+			http.Error(w, "Failed to load seating plan", http.StatusInternalServerError)
+			return
+		}
+
+		templates.SeatingBoard(plan, errorMsg).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminRegistry manages the gift registry and lists who pledged what for the thank-you notes
+func HandleAdminRegistry() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Form.Get("action") {
+			case "item":
+				targetAmount, err := strconv.Atoi(r.Form.Get("target_amount"))
+				if err != nil {
+					http.Error(w, "Invalid target amount", http.StatusBadRequest)
+					return
+				}
+				titleEN := r.Form.Get("title_en")
+				titleRO := r.Form.Get("title_ro")
+				descriptionEN := r.Form.Get("description_en")
+				descriptionRO := r.Form.Get("description_ro")
+
+				if idStr := r.Form.Get("id"); idStr != "" {
+					id, parseErr := strconv.ParseInt(idStr, 10, 64)
+					if parseErr != nil {
+						http.Error(w, "Invalid registry item", http.StatusBadRequest)
+						return
+					}
+					err = models.UpdateRegistryItem(id, titleEN, titleRO, descriptionEN, descriptionRO, targetAmount, r.Form.Get("active") == "true")
+				} else {
+					err = models.CreateRegistryItem(r.Form.Get("kind"), titleEN, titleRO, descriptionEN, descriptionRO, targetAmount)
+				}
+				if err != nil {
+					log.Printf("Error saving registry item: %v", err)
+					http.Error(w, "Failed to save registry item", http.StatusBadRequest)
+					return
+				}
+			case "thanked":
+				id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid pledge", http.StatusBadRequest)
+					return
+				}
+				if err := models.SetPledgeThanked(id, r.Form.Get("thanked") == "true"); err != nil {
+					log.Printf("Error marking pledge %d thanked: %v", id, err)
+					http.Error(w, "Failed to update pledge", http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/registry?success=true", http.StatusSeeOther)
+			return
+		}
+
+		items, err := models.GetRegistryItems(false)
+		if err != nil {
+			log.Printf("Error fetching registry: %v", err)
+			http.Error(w, "Failed to load the registry", http.StatusInternalServerError)
+			return
+		}
+
+		pledges, err := models.GetRegistryPledges()
+		if err != nil {
+			log.Printf("Error fetching pledges: %v", err)
+			http.Error(w, "Failed to load the registry", http.StatusInternalServerError)
+			return
+		}
+
+		guests, err := models.GetAllGuests()
+		if err != nil {
+			log.Printf("Error fetching all guests: %v", err)
+			http.Error(w, "Failed to load guest data", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Registry has been updated."
+		}
+
+		templates.AdminRegistry(items, pledges, guests, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminSongs moderates the guests' song requests
+func HandleAdminSongs() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid song request", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.SetSongStatus(id, r.Form.Get("status")); err != nil {
+				log.Printf("Error moderating song request %d: %v", id, err)
+				http.Error(w, "Failed to update song request", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/songs?success=true", http.StatusSeeOther)
+			return
+		}
+
+		songs, err := models.GetSongRequests("")
+		if err != nil {
+			log.Printf("Error fetching song requests: %v", err)
+			http.Error(w, "Failed to load song requests", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Song request has been updated."
+		}
+
+		templates.AdminSongs(songs, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminGuestbook is the moderation queue of the guestbook
+func HandleAdminGuestbook() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid guestbook entry", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.SetGuestbookStatus(id, r.Form.Get("status")); err != nil {
+				log.Printf("Error moderating guestbook entry %d: %v", id, err)
+				http.Error(w, "Failed to update guestbook entry", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/guestbook?success=true", http.StatusSeeOther)
+			return
+		}
+
+		entries, err := models.GetGuestbookEntries()
+		if err != nil {
+			log.Printf("Error fetching guestbook: %v", err)
+			http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Guestbook entry has been updated."
+		}
+
+		templates.AdminGuestbook(entries, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminPhotos is the moderation queue of the photos guests uploaded
+func HandleAdminPhotos() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid photo", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Form.Get("action") {
+			case "status":
+				err = models.SetGuestPhotoStatus(id, r.Form.Get("status"))
+			case "delete":
+				err = models.DeleteGuestPhoto(id, "")
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("Error moderating photo %d: %v", id, err)
+				http.Error(w, "Failed to update photo", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/photos?success=true", http.StatusSeeOther)
+			return
+		}
+
+		photos, err := models.GetGuestPhotos()
+		if err != nil {
+			log.Printf("Error fetching photos: %v", err)
+			http.Error(w, "Failed to load photos", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Photo has been updated."
+		}
+
+		templates.AdminPhotos(photos, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminPhotoFile serves any uploaded photo or thumbnail, whatever its status
+func HandleAdminPhotoFile() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if photo := lookupPhoto(w, r); photo != nil {
+			servePhotoFile(w, r, photo)
+		}
+	}))
+}
+
+// HandleAdminGallery manages the albums and photos of the couple's gallery
+func HandleAdminGallery() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			r.Body = http.MaxBytesReader(w, r.Body, 100<<20)
+			if err := r.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+			if r.MultipartForm != nil {
+				defer r.MultipartForm.RemoveAll()
+			}
+
+			sortOrder, _ := strconv.Atoi(r.FormValue("sort_order"))
+			id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+			albumID, _ := strconv.ParseInt(r.FormValue("album_id"), 10, 64)
+
+			var err error
+			redirect := "/admin/gallery?success=true"
+			switch r.FormValue("action") {
+			case "album":
+				if id == 0 {
+					err = models.CreateGalleryAlbum(r.FormValue("slug"), r.FormValue("title_en"), r.FormValue("title_ro"), sortOrder)
+				} else {
+					err = models.UpdateGalleryAlbum(id, r.FormValue("title_en"), r.FormValue("title_ro"), sortOrder)
+				}
+			case "delete_album":
+				err = models.DeleteGalleryAlbum(id)
+			case "upload":
+				if r.MultipartForm == nil || len(r.MultipartForm.File["photos"]) == 0 {
+					http.Error(w, "No photos uploaded", http.StatusBadRequest)
+					return
+				}
+				for _, header := range r.MultipartForm.File["photos"] {
+					if err = addGalleryUpload(albumID, header); err != nil {
+						err = fmt.Errorf("%s: %w", header.Filename, err)
+						break
+					}
+				}
+			case "photo":
+				err = models.UpdateGalleryPhoto(id, albumID, r.FormValue("caption_en"), r.FormValue("caption_ro"),
+					sortOrder, r.FormValue("featured") == "true")
+			case "delete_photo":
+				err = models.DeleteGalleryPhoto(id)
+			case "regenerate":
+				var count int
+				count, err = models.RegenerateGalleryVariants()
+				redirect = fmt.Sprintf("/admin/gallery?regenerated=%d", count)
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("Error updating gallery: %v", err)
+				http.Error(w, "Failed to update gallery: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, redirect, http.StatusSeeOther)
+			return
+		}
+
+		albums, err := models.GetGalleryAlbums()
+		if err != nil {
+			log.Printf("Error fetching gallery: %v", err)
+			http.Error(w, "Failed to load gallery", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Gallery has been updated."
+		} else if regenerated := r.URL.Query().Get("regenerated"); regenerated != "" {
+			successMsg = fmt.Sprintf("Resized copies of %s photos have been regenerated.", regenerated)
+		}
+
+		templates.AdminGallery(albums, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// addGalleryUpload adds one uploaded file to a gallery album
+func addGalleryUpload(albumID int64, header *multipart.FileHeader) error {
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	_, err = models.AddGalleryPhoto(albumID, data, "", "")
+	return err
+}
+
+// HandleAdminStory edits the "Our Story" timeline
+func HandleAdminStory() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, _ := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			photoID, _ := strconv.ParseInt(r.Form.Get("photo_id"), 10, 64)
+
+			var err error
+			switch r.Form.Get("action") {
+			case "entry":
+				if id == 0 {
+					err = models.CreateStoryEntry(r.Form.Get("happened_on"), r.Form.Get("title_en"), r.Form.Get("title_ro"),
+						r.Form.Get("body_en"), r.Form.Get("body_ro"), photoID)
+				} else {
+					err = models.UpdateStoryEntry(id, r.Form.Get("happened_on"), r.Form.Get("title_en"), r.Form.Get("title_ro"),
+						r.Form.Get("body_en"), r.Form.Get("body_ro"), photoID)
+				}
+			case "delete":
+				err = models.DeleteStoryEntry(id)
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("Error updating story: %v", err)
+				http.Error(w, "Failed to update story: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/story?success=true", http.StatusSeeOther)
+			return
+		}
+
+		entries, err := models.GetStoryEntries()
+		if err != nil {
+			log.Printf("Error fetching story: %v", err)
+			http.Error(w, "Failed to load story", http.StatusInternalServerError)
+			return
+		}
+
+		albums, err := models.GetGalleryAlbums()
+		if err != nil {
+			log.Printf("Error fetching gallery: %v", err)
+			http.Error(w, "Failed to load gallery", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Story has been updated."
+		}
+
+		templates.AdminStory(entries, albums, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminFAQ edits the FAQ categories and their questions
+func HandleAdminFAQ() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, _ := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			sortOrder, _ := strconv.Atoi(r.Form.Get("sort_order"))
+
+			var err error
+			switch r.Form.Get("action") {
+			case "category":
+				if id == 0 {
+					err = models.CreateFAQCategory(r.Form.Get("title_en"), r.Form.Get("title_ro"), sortOrder)
+				} else {
+					err = models.UpdateFAQCategory(id, r.Form.Get("title_en"), r.Form.Get("title_ro"), sortOrder)
+				}
+			case "delete_category":
+				err = models.DeleteFAQCategory(id)
+			case "entry":
+				categoryID, _ := strconv.ParseInt(r.Form.Get("category_id"), 10, 64)
+				if id == 0 {
+					err = models.CreateFAQEntry(categoryID, r.Form.Get("question_en"), r.Form.Get("question_ro"),
+						r.Form.Get("answer_en"), r.Form.Get("answer_ro"), sortOrder, r.Form["events"])
+				} else {
+					err = models.UpdateFAQEntry(id, categoryID, r.Form.Get("question_en"), r.Form.Get("question_ro"),
+						r.Form.Get("answer_en"), r.Form.Get("answer_ro"), sortOrder, r.Form["events"])
+				}
+			case "delete_entry":
+				err = models.DeleteFAQEntry(id)
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("Error updating FAQ: %v", err)
+				http.Error(w, "Failed to update FAQ: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/faq?success=true", http.StatusSeeOther)
+			return
+		}
+
+		categories, err := models.GetFAQ()
+		if err != nil {
+			log.Printf("Error fetching FAQ: %v", err)
+			http.Error(w, "Failed to load FAQ", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "FAQ has been updated."
+		}
+
+		templates.AdminFAQ(categories, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminQuestions is the inbox of guest questions, where admins reply to them
+func HandleAdminQuestions() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid question", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Form.Get("action") {
+			case "status":
+				if err := models.SetGuestQuestionStatus(id, r.Form.Get("status")); err != nil {
+					log.Printf("Error updating question %d: %v", id, err)
+					http.Error(w, "Failed to update question: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			case "reply":
+				replyID, err := models.ReplyToGuestQuestion(id, r.Form.Get("reply"))
+				if err != nil {
+					log.Printf("Error replying to question %d: %v", id, err)
+					http.Error(w, "Failed to reply: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				question, err := models.GetGuestQuestion(id)
+				if err == nil {
+					err = emailReply(question, question.Replies[len(question.Replies)-1].Body)
+				}
+				if err == nil {
+					err = models.SetQuestionReplyEmailed(replyID)
+				}
+				if err != nil {
+					// The guest still sees the reply on their questions page
+					log.Printf("Error emailing reply to question %d: %v", id, err)
+					http.Redirect(w, r, "/admin/questions?success=unsent", http.StatusSeeOther)
+					return
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/questions?success=true", http.StatusSeeOther)
+			return
+		}
+
+		questions, err := models.GetGuestQuestions()
+		if err != nil {
+			log.Printf("Error fetching questions: %v", err)
+			http.Error(w, "Failed to load questions", http.StatusInternalServerError)
+			return
+		}
+
+		guests, err := models.GetAllGuests()
+		if err != nil {
+			log.Printf("Error fetching guests: %v", err)
+			http.Error(w, "Failed to load guests", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		switch r.URL.Query().Get("success") {
+		case "true":
+			successMsg = "Question has been updated."
+		case "unsent":
+			successMsg = "Reply saved, but the email could not be sent. The guest will still see it on their questions page."
+		}
+
+		templates.AdminQuestions(questions, guests, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminTravel edits the places on the travel page
+func HandleAdminTravel() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, _ := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+
+			var err error
+			switch r.Form.Get("action") {
+			case "place":
+				latitude, latErr := models.ParseCoordinate(r.Form.Get("latitude"))
+				longitude, lonErr := models.ParseCoordinate(r.Form.Get("longitude"))
+				if latErr != nil || lonErr != nil {
+					http.Error(w, "Invalid coordinates", http.StatusBadRequest)
+					return
+				}
+				sortOrder, _ := strconv.Atoi(r.Form.Get("sort_order"))
+
+				if id == 0 {
+					err = models.CreateTravelPlace(r.Form.Get("kind"), r.Form.Get("name"), r.Form.Get("address"), latitude, longitude,
+						r.Form.Get("notes_en"), r.Form.Get("notes_ro"), r.Form.Get("website"), sortOrder)
+				} else {
+					err = models.UpdateTravelPlace(id, r.Form.Get("kind"), r.Form.Get("name"), r.Form.Get("address"), latitude, longitude,
+						r.Form.Get("notes_en"), r.Form.Get("notes_ro"), r.Form.Get("website"), sortOrder)
+				}
+			case "delete":
+				err = models.DeleteTravelPlace(id)
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				log.Printf("Error updating travel places: %v", err)
+				http.Error(w, "Failed to update travel places: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/admin/travel?success=true", http.StatusSeeOther)
+			return
+		}
+
+		places, err := models.GetTravelPlaces()
+		if err != nil {
+			log.Printf("Error fetching travel places: %v", err)
+			http.Error(w, "Failed to load travel places", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "Travel places have been updated."
+		}
+
+		templates.AdminTravel(places, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminMenu lets admins add, rename, reorder and retire menu options
+func HandleAdminMenu() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			sortOrder, err := strconv.Atoi(r.Form.Get("sort_order"))
+			if err != nil {
+				http.Error(w, "Invalid sort order", http.StatusBadRequest)
+				return
+			}
+			labelEN := r.Form.Get("label_en")
+			labelRO := r.Form.Get("label_ro")
+			childrenOnly := r.Form.Get("children_only") == "true"
+
+			if idStr := r.Form.Get("id"); idStr != "" {
+				id, err := strconv.ParseInt(idStr, 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid meal option", http.StatusBadRequest)
+					return
+				}
+
+				active := r.Form.Get("active") == "true"
+				err = models.UpdateMealOption(id, labelEN, labelRO, sortOrder, active, childrenOnly)
+				if err != nil {
+					log.Printf("Error updating meal option %d: %v", id, err)
+					http.Error(w, "Failed to update meal option", http.StatusBadRequest)
+					return
+				}
+			} else {
+				err = models.CreateMealOption(labelEN, labelRO, sortOrder, childrenOnly)
+				if errors.Is(err, models.ErrMealOptionExists) {
+					http.Error(w, "Failed to create meal option: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				if err != nil {
+					log.Printf("Error creating meal option: %v", err)
+					http.Error(w, "Failed to create meal option", http.StatusBadRequest)
+					return
+				}
+			}
+
+			http.Redirect(w, r, "/admin/menu?success=true", http.StatusSeeOther)
+			return
+		}
+
+		mealOptions, err := models.GetAllMealOptions()
+		if err != nil {
+			log.Printf("Error fetching meal options: %v", err)
+			http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+			return
+		}
+
+		courseChoices, err := models.GetAllCourseChoices()
+		if err != nil {
+			log.Printf("Error fetching course choices: %v", err)
+			http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+			return
+		}
+
+		successMsg := ""
+		if r.URL.Query().Get("success") == "true" {
+			successMsg = "The menu has been updated."
+		}
+
+		templates.AdminMenu(mealOptions, courseChoices, successMsg, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleAdminCourseChoices lets admins add, rename, restrict and retire the dishes of each course
+func HandleAdminCourseChoices() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Redirect(w, r, "/admin/menu", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		sortOrder, err := strconv.Atoi(r.Form.Get("sort_order"))
+		if err != nil {
+			http.Error(w, "Invalid sort order", http.StatusBadRequest)
+			return
+		}
+		labelEN := r.Form.Get("label_en")
+		labelRO := r.Form.Get("label_ro")
+		mealTypes := r.Form["meal_types[]"]
+
+		if idStr := r.Form.Get("id"); idStr != "" {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid course choice", http.StatusBadRequest)
+				return
+			}
+
+			active := r.Form.Get("active") == "true"
+			err = models.UpdateCourseChoice(id, labelEN, labelRO, sortOrder, active, mealTypes)
+			if err != nil {
+				log.Printf("Error updating course choice %d: %v", id, err)
+				http.Error(w, "Failed to update course choice", http.StatusBadRequest)
+				return
+			}
+		} else {
+			err = models.CreateCourseChoice(r.Form.Get("course"), labelEN, labelRO, sortOrder, mealTypes)
+			if err != nil {
+				log.Printf("Error creating course choice: %v", err)
+				http.Error(w, "Failed to create course choice", http.StatusBadRequest)
+				return
+			}
+		}
+
+		http.Redirect(w, r, "/admin/menu?success=true", http.StatusSeeOther)
 	}))
 }
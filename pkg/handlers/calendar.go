@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+)
+
+// icsTimeFormat is the UTC date-time format used by iCalendar
+const icsTimeFormat = "20060102T150405Z"
+
+// HandleCalendar serves an iCalendar file with the events the invitation may attend
+func HandleCalendar() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		events, err := models.GetInvitationEvents(session.InvitationEmail)
+		if err != nil {
+			log.Printf("Error fetching invitation events: %v", err)
+			http.Error(w, "Failed to load event data", http.StatusInternalServerError)
+			return
+		}
+
+		lang := middleware.GetLanguage(r)
+		now := time.Now().UTC().Format(icsTimeFormat)
+
+		var b strings.Builder
+		writeICSLine(&b, "BEGIN:VCALENDAR")
+		writeICSLine(&b, "VERSION:2.0")
+		writeICSLine(&b, "PRODID:-//Ramona & Bogdan//Wedding//EN")
+		writeICSLine(&b, "CALSCALE:GREGORIAN")
+		writeICSLine(&b, "METHOD:PUBLISH")
+		for _, event := range events {
+			writeICSLine(&b, "BEGIN:VEVENT")
+			writeICSLine(&b, "UID:"+event.Key+"@wedding.bogdanfloris.com")
+			writeICSLine(&b, "DTSTAMP:"+now)
+			writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+			writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+			writeICSLine(&b, "SUMMARY:"+escapeICSText(i18n.T(lang, event.Key+".title")))
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(i18n.T(lang, event.Key+".description")))
+			writeICSLine(&b, "LOCATION:"+escapeICSText(fmt.Sprintf(
+				"%s, %s",
+				i18n.T(lang, event.Key+".venue"),
+				i18n.T(lang, event.Key+".address"),
+			)))
+			writeICSLine(&b, "URL:"+event.MapURL)
+			writeICSLine(&b, "END:VEVENT")
+		}
+		writeICSLine(&b, "END:VCALENDAR")
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="wedding.ics"`)
+		w.Write([]byte(b.String()))
+	}))
+}
+
+// writeICSLine writes a content line, folding it at 75 octets as RFC 5545 requires
+func writeICSLine(b *strings.Builder, line string) {
+	// Continuation lines start with a space, which counts towards the limit
+	limit := 75
+	for len(line) > limit {
+		// Never split a multi-byte UTF-8 sequence
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// escapeICSText escapes the characters that have a special meaning in iCalendar text values
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	).Replace(s)
+}
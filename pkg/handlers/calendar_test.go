@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWriteICSLine(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short line", "SUMMARY:Cununia religioasă", "SUMMARY:Cununia religioasă\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"continuation lines hold 74 octets", strings.Repeat("a", 75+74+1),
+			strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		{"multi-byte character on the fold", strings.Repeat("a", 74) + "ă",
+			strings.Repeat("a", 74) + "\r\n ă\r\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICSLine(&b, tc.line)
+			if got := b.String(); got != tc.want {
+				t.Errorf("writeICSLine(%q)\n got %q\nwant %q", tc.line, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteICSLineUnfolds(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("Vă așteptăm la Palatul Ghica Tei\\, București. ", 8)
+
+	var b strings.Builder
+	writeICSLine(&b, line)
+
+	folded := strings.TrimSuffix(b.String(), "\r\n")
+	for _, physical := range strings.Split(folded, "\r\n") {
+		if len(physical) > 75 {
+			t.Errorf("line of %d octets: %q", len(physical), physical)
+		}
+		if !utf8.ValidString(physical) {
+			t.Errorf("line splits a character: %q", physical)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolded line\n got %q\nwant %q", unfolded, line)
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	got := escapeICSText("Str. Icoanei 12, București; parcare\\acces\nintrarea din spate")
+	want := `Str. Icoanei 12\, București\; parcare\\acces\nintrarea din spate`
+	if got != want {
+		t.Errorf("escapeICSText\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/pkg/printing"
+)
+
+// writeCSV sends rows as a CSV file download
+func writeCSV(w http.ResponseWriter, filename string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		log.Printf("Error writing %s: %v", filename, err)
+	}
+}
+
+// HandleShuttleManifest exports the passenger list of a shuttle run for the driver
+func HandleShuttleManifest() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		runID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid shuttle run", http.StatusBadRequest)
+			return
+		}
+
+		run, passengers, err := models.GetShuttleManifest(runID)
+		if err != nil {
+			log.Printf("Error fetching manifest for shuttle run %d: %v", runID, err)
+			http.Error(w, "Shuttle run not found", http.StatusNotFound)
+			return
+		}
+
+		departs := run.DepartsAt.In(models.RSVPLocation()).Format(models.DeadlineInputFormat)
+		rows := [][]string{
+			{run.LabelEN, departs, fmt.Sprintf("%d/%d", len(passengers), run.Capacity)},
+			{"#", "Name", "Invitation", "Age", "Assistance"},
+		}
+		for i, guest := range passengers {
+			age := i18n.T("en", "age_categories."+guest.AgeCategory)
+			if guest.Age.Valid {
+				age = fmt.Sprintf("%s (%d)", age, guest.Age.Int64)
+			}
+
+			var assistance []string
+			for _, need := range guest.AccessibilityNeeds {
+				assistance = append(assistance, i18n.T("en", "accessibility."+need))
+			}
+			if guest.AccessibilityNotes.Valid && guest.AccessibilityNotes.String != "" {
+				assistance = append(assistance, guest.AccessibilityNotes.String)
+			}
+
+			rows = append(rows, []string{
+				strconv.Itoa(i + 1),
+				guest.Name,
+				guest.InvitationEmail,
+				age,
+				strings.Join(assistance, "; "),
+			})
+		}
+
+		writeCSV(w, fmt.Sprintf("shuttle-%d-manifest.csv", run.ID), rows)
+	}))
+}
+
+// HandleRoomingList exports a hotel's confirmed room allocations for its reservations desk
+func HandleRoomingList() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hotelID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid hotel", http.StatusBadRequest)
+			return
+		}
+
+		hotel, requests, err := models.GetRoomingList(hotelID)
+		if err != nil {
+			log.Printf("Error fetching rooming list for hotel %d: %v", hotelID, err)
+			http.Error(w, "Hotel not found", http.StatusNotFound)
+			return
+		}
+
+		rows := [][]string{
+			{hotel.Name},
+			{"Invitation", "Guests", "Room Type", "Rooms", "Check-in", "Check-out", "Nights", "Notes"},
+		}
+		for _, request := range requests {
+			guests, err := models.GetGuestsByInvitation(request.InvitationEmail)
+			if err != nil {
+				log.Printf("Error fetching guests of %s: %v", request.InvitationEmail, err)
+			}
+
+			// List the guests who are coming, since they are the ones staying
+			var names []string
+			for _, guest := range guests {
+				if guest.Attending.Valid && guest.Attending.Bool {
+					names = append(names, guest.Name)
+				}
+			}
+
+			rows = append(rows, []string{
+				request.InvitationEmail,
+				strings.Join(names, "; "),
+				request.RoomTypeEN,
+				strconv.Itoa(request.Rooms),
+				request.CheckIn.Format(models.DateInputFormat),
+				request.CheckOut.Format(models.DateInputFormat),
+				strconv.Itoa(request.Nights()),
+				request.Notes,
+			})
+		}
+
+		writeCSV(w, fmt.Sprintf("hotel-%d-rooming-list.csv", hotel.ID), rows)
+	}))
+}
+
+// HandleSeatingPrint serves the seating plan as a print-ready PDF: place cards, the escort
+// card list or the waiters' table sheets, in the language chosen with ?lang=
+func HandleSeatingPrint() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plan, err := models.GetSeatingPlan()
+		if err != nil {
+			log.Printf("Error fetching seating plan: %v", err)
+			http.Error(w, "Failed to load seating plan", http.StatusInternalServerError)
+			return
+		}
+
+		// Include retired options, guests may still have them selected
+		mealOptions, err := models.GetAllMealOptions()
+		if err != nil {
+			log.Printf("Error fetching meal options: %v", err)
+			http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+			return
+		}
+
+		lang := middleware.GetLanguage(r)
+		doc := r.URL.Query().Get("doc")
+
+		// Render fully before sending, so a failure is not served as a truncated PDF
+		var buf bytes.Buffer
+		switch doc {
+		case "place-cards":
+			err = printing.PlaceCards(&buf, plan, mealOptions, lang)
+		case "escort-cards":
+			err = printing.EscortList(&buf, plan, lang)
+		case "table-sheets":
+			err = printing.TableSheets(&buf, plan, mealOptions, lang)
+		default:
+			http.Error(w, "Unknown document", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error rendering %s: %v", doc, err)
+			http.Error(w, "Failed to render document", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.pdf"`, doc, lang))
+		buf.WriteTo(w)
+	}))
+}
+
+// HandleSongExport gives the DJ the song requests, either every request as CSV or the
+// playlist of must-play and approved songs as M3U
+func HandleSongExport() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("format") {
+		case "csv":
+			songs, err := models.GetSongRequests("")
+			if err != nil {
+				log.Printf("Error fetching song requests: %v", err)
+				http.Error(w, "Failed to load song requests", http.StatusInternalServerError)
+				return
+			}
+
+			rows := [][]string{{"Title", "Artist", "Status", "Votes", "Dedication", "Requested By"}}
+			for _, song := range songs {
+				rows = append(rows, []string{
+					song.Title,
+					song.Artist,
+					song.Status,
+					strconv.Itoa(song.Votes),
+					song.Dedication,
+					song.InvitationEmail,
+				})
+			}
+
+			writeCSV(w, "song-requests.csv", rows)
+		case "m3u":
+			songs, err := models.GetPlaylist()
+			if err != nil {
+				log.Printf("Error fetching playlist: %v", err)
+				http.Error(w, "Failed to load playlist", http.StatusInternalServerError)
+				return
+			}
+
+			// Entries carry no file paths; the DJ's software matches them by artist and title
+			w.Header().Set("Content-Type", "audio/x-mpegurl; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="playlist.m3u8"`)
+			fmt.Fprintln(w, "#EXTM3U")
+			for _, song := range songs {
+				name := strings.NewReplacer("\r", " ", "\n", " ").Replace(song.Artist + " - " + song.Title)
+				fmt.Fprintf(w, "#EXTINF:-1,%s\n%s\n", name, name)
+			}
+		default:
+			http.Error(w, "Unknown format", http.StatusBadRequest)
+		}
+	}))
+}
+
+// HandleGuestbookExport exports the guestbook, either every message as CSV or the approved
+// messages as a PDF keepsake book in the requested language
+func HandleGuestbookExport() http.Handler {
+	return middleware.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("format") {
+		case "csv":
+			entries, err := models.GetGuestbookEntries()
+			if err != nil {
+				log.Printf("Error fetching guestbook: %v", err)
+				http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+				return
+			}
+
+			rows := [][]string{{"Author", "Invitation", "Language", "Status", "Written", "Edited", "Message"}}
+			for _, entry := range entries {
+				rows = append(rows, []string{
+					entry.AuthorName,
+					entry.InvitationEmail,
+					entry.Language,
+					entry.Status,
+					entry.CreatedAt.Format("2006-01-02 15:04"),
+					entry.UpdatedAt.Format("2006-01-02 15:04"),
+					entry.Message,
+				})
+			}
+
+			writeCSV(w, "guestbook.csv", rows)
+		case "pdf":
+			entries, err := models.GetApprovedGuestbookEntries()
+			if err != nil {
+				log.Printf("Error fetching guestbook: %v", err)
+				http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+				return
+			}
+
+			lang := middleware.GetLanguage(r)
+			var buf bytes.Buffer
+			if err := printing.Guestbook(&buf, entries, lang); err != nil {
+				log.Printf("Error rendering guestbook: %v", err)
+				http.Error(w, "Failed to render document", http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="guestbook-%s.pdf"`, lang))
+			buf.WriteTo(w)
+		default:
+			http.Error(w, "Unknown format", http.StatusBadRequest)
+		}
+	}))
+}
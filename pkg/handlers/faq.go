@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleFAQ shows the questions and answers visible to the invitation, optionally searched
+func HandleFAQ() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		search := strings.TrimSpace(r.URL.Query().Get("q"))
+
+		categories, err := models.GetInvitationFAQ(session.InvitationEmail, middleware.GetLanguage(r), search)
+		if err != nil {
+			log.Printf("Error fetching FAQ: %v", err)
+			http.Error(w, "Failed to load the FAQ", http.StatusInternalServerError)
+			return
+		}
+
+		templates.FAQ(categories, search, r).Render(r.Context(), w)
+	}))
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/pkg/storage"
+	"wedding-invite/templates"
+)
+
+// HandleGallery shows the couple's photo albums
+func HandleGallery() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		albums, err := models.GetGalleryAlbums()
+		if err != nil {
+			log.Printf("Error fetching gallery: %v", err)
+			http.Error(w, "Failed to load gallery", http.StatusInternalServerError)
+			return
+		}
+
+		templates.Gallery(albums, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleGalleryFile serves a resized copy of a gallery photo, picked by its width
+func HandleGalleryFile() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid photo", http.StatusBadRequest)
+			return
+		}
+		width, err := strconv.Atoi(r.URL.Query().Get("w"))
+		if err != nil {
+			http.Error(w, "Invalid width", http.StatusBadRequest)
+			return
+		}
+
+		photo, err := models.GetGalleryPhoto(id)
+		if err != nil {
+			log.Printf("Error fetching gallery photo %d: %v", id, err)
+			http.Error(w, "Failed to load photo", http.StatusInternalServerError)
+			return
+		}
+		if photo == nil || !photo.HasVariant(width) {
+			http.NotFound(w, r)
+			return
+		}
+
+		file, err := storage.Uploads.Open(photo.VariantKey(width))
+		if err != nil {
+			log.Printf("Error opening gallery photo %d at %dpx: %v", id, width, err)
+			http.NotFound(w, r)
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		io.Copy(w, file)
+	}))
+}
+
+// HandleGalleryDownload sends the original photos of an album as a zip file
+func HandleGalleryDownload() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		album, err := models.GetGalleryAlbum(r.URL.Query().Get("album"))
+		if err != nil {
+			log.Printf("Error fetching gallery album: %v", err)
+			http.Error(w, "Failed to load album", http.StatusInternalServerError)
+			return
+		}
+		if album == nil || len(album.Photos) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, album.Slug))
+
+		// Photos are already compressed, so they are stored as they are and streamed
+		archive := zip.NewWriter(w)
+		for i, photo := range album.Photos {
+			name := fmt.Sprintf("%s-%02d%s", album.Slug, i+1, path.Ext(photo.FileKey))
+			if err := addToArchive(archive, name, photo.FileKey); err != nil {
+				// Headers are sent; all that is left is to cut the download short
+				log.Printf("Error adding %s to album download: %v", photo.FileKey, err)
+				return
+			}
+		}
+		if err := archive.Close(); err != nil {
+			log.Printf("Error finishing album download: %v", err)
+		}
+	}))
+}
+
+// addToArchive copies a stored file into a zip archive under name
+func addToArchive(archive *zip.Writer, name, key string) error {
+	file, err := storage.Uploads.Open(key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, file)
+	return err
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleGuestbook shows the approved guestbook messages and lets the invitee write, edit
+// and delete their own
+func HandleGuestbook() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			notice := ""
+			action := r.Form.Get("action")
+			if action == "write" {
+				err = models.CreateGuestbookEntry(email, r.Form.Get("name"), r.Form.Get("message"), r.Form.Get("language"))
+				notice = "submitted"
+			} else {
+				id, parseErr := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+				if parseErr != nil {
+					http.Error(w, "Invalid guestbook entry", http.StatusBadRequest)
+					return
+				}
+
+				switch action {
+				case "edit":
+					err = models.UpdateGuestbookEntry(id, email, r.Form.Get("name"), r.Form.Get("message"), r.Form.Get("language"))
+					notice = "updated"
+				case "delete":
+					err = models.DeleteGuestbookEntry(id, email)
+					notice = "deleted"
+				default:
+					http.Error(w, "Unknown action", http.StatusBadRequest)
+					return
+				}
+			}
+
+			if errors.Is(err, models.ErrGuestbookTooLong) {
+				notice = "too_long"
+			} else if err != nil {
+				log.Printf("Error updating guestbook for %s: %v", email, err)
+				notice = "invalid"
+			}
+
+			http.Redirect(w, r, "/guestbook?notice="+notice, http.StatusSeeOther)
+			return
+		}
+
+		entries, err := models.GetApprovedGuestbookEntries()
+		if err != nil {
+			log.Printf("Error fetching guestbook: %v", err)
+			http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+			return
+		}
+
+		own, err := models.GetInvitationGuestbookEntries(email)
+		if err != nil {
+			log.Printf("Error fetching guestbook entries for %s: %v", email, err)
+			http.Error(w, "Failed to load guestbook", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "submitted", "updated", "deleted", "too_long", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Guestbook(email, entries, own, notice, r).Render(r.Context(), w)
+	}))
+}
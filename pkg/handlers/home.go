@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"wedding-invite/pkg/auth"
 	"wedding-invite/pkg/middleware"
@@ -53,17 +54,30 @@ func Wedding() http.Handler {
 			return
 		}
 
-		// Check if the user has any RSVPs
+		// Check if the user has any RSVPs; named guests exist before anyone answers
 		email := session.InvitationEmail
-		guestCount, err := models.GetGuestCount(email)
+		hasRSVP, err := models.HasResponded(email)
 		if err != nil {
-			// If there's an error, assume no guests to be safe
-			guestCount = 0
+			// If there's an error, assume no RSVP to be safe
+			hasRSVP = false
 		}
 
-		hasRSVP := guestCount > 0
+		// Only show the events this invitation may attend
+		events, err := models.GetInvitationEvents(email)
+		if err != nil {
+			log.Printf("Error fetching invitation events: %v", err)
+			http.Error(w, "Failed to load event data", http.StatusInternalServerError)
+			return
+		}
+
+		featured, err := models.GetFeaturedGalleryPhotos()
+		if err != nil {
+			log.Printf("Error fetching featured photos: %v", err)
+			http.Error(w, "Failed to load photos", http.StatusInternalServerError)
+			return
+		}
 
 		// Render wedding info page
-		templates.Wedding(email, hasRSVP, r).Render(r.Context(), w)
+		templates.Wedding(email, hasRSVP, events, featured, r).Render(r.Context(), w)
 	}))
 }
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"wedding-invite/pkg/imaging"
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/pkg/storage"
+	"wedding-invite/templates"
+)
+
+// HandlePhotos lets the invitee upload their photos of the wedding, see which were approved
+// and browse the approved photos of every guest
+func HandlePhotos() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			r.Body = http.MaxBytesReader(w, r.Body, models.MaxPhotosPerUpload*models.MaxPhotoSize+1<<20)
+			err := r.ParseMultipartForm(32 << 20)
+			if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				log.Printf("Error reading photo upload from %s: %v", email, err)
+				http.Redirect(w, r, "/photos?notice=too_large", http.StatusSeeOther)
+				return
+			}
+			if r.MultipartForm != nil {
+				defer r.MultipartForm.RemoveAll()
+			}
+
+			notice := ""
+			switch r.FormValue("action") {
+			case "upload":
+				notice = uploadPhotos(email, r)
+			case "delete":
+				id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+				if err != nil {
+					http.Error(w, "Invalid photo", http.StatusBadRequest)
+					return
+				}
+				notice = "deleted"
+				if err := models.DeleteGuestPhoto(id, email); err != nil {
+					log.Printf("Error deleting photo %d for %s: %v", id, email, err)
+					notice = "invalid"
+				}
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			http.Redirect(w, r, "/photos?notice="+notice, http.StatusSeeOther)
+			return
+		}
+
+		photos, err := models.GetApprovedGuestPhotos()
+		if err != nil {
+			log.Printf("Error fetching photos: %v", err)
+			http.Error(w, "Failed to load photos", http.StatusInternalServerError)
+			return
+		}
+
+		own, err := models.GetInvitationGuestPhotos(email)
+		if err != nil {
+			log.Printf("Error fetching photos for %s: %v", email, err)
+			http.Error(w, "Failed to load photos", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "uploaded", "deleted", "quota", "too_large", "type", "too_many", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Photos(email, photos, own, notice, r).Render(r.Context(), w)
+	}))
+}
+
+// uploadPhotos stores the photos of an upload form and returns the notice to show.
+// It stops at the first photo that fails, keeping the ones before it.
+func uploadPhotos(email string, r *http.Request) string {
+	if r.MultipartForm == nil {
+		return "invalid"
+	}
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		return "invalid"
+	}
+	if len(files) > models.MaxPhotosPerUpload {
+		return "too_many"
+	}
+
+	for _, header := range files {
+		if header.Size > models.MaxPhotoSize {
+			return "too_large"
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			log.Printf("Error opening uploaded photo: %v", err)
+			return "invalid"
+		}
+		data, err := io.ReadAll(io.LimitReader(file, models.MaxPhotoSize+1))
+		file.Close()
+		if err != nil {
+			log.Printf("Error reading uploaded photo: %v", err)
+			return "invalid"
+		}
+
+		err = models.UploadGuestPhoto(email, data)
+		switch {
+		case err == nil:
+		case errors.Is(err, models.ErrPhotoQuota):
+			return "quota"
+		case errors.Is(err, models.ErrPhotoTooLarge), errors.Is(err, imaging.ErrTooManyPixels):
+			return "too_large"
+		case errors.Is(err, imaging.ErrUnsupported):
+			return "type"
+		default:
+			log.Printf("Error storing photo from %s: %v", email, err)
+			return "invalid"
+		}
+	}
+
+	return "uploaded"
+}
+
+// HandlePhotoFile serves a photo or its thumbnail to guests: approved photos to everyone,
+// and their own uploads to the invitation that sent them
+func HandlePhotoFile() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		photo := lookupPhoto(w, r)
+		if photo == nil {
+			return
+		}
+		if photo.Status != models.PhotoApproved && photo.InvitationEmail != session.InvitationEmail {
+			http.NotFound(w, r)
+			return
+		}
+
+		servePhotoFile(w, r, photo)
+	}))
+}
+
+// lookupPhoto finds the photo named by the id query parameter, answering the request itself
+// when there is none
+func lookupPhoto(w http.ResponseWriter, r *http.Request) *models.GuestPhoto {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid photo", http.StatusBadRequest)
+		return nil
+	}
+
+	photo, err := models.GetGuestPhoto(id)
+	if err != nil {
+		log.Printf("Error fetching photo %d: %v", id, err)
+		http.Error(w, "Failed to load photo", http.StatusInternalServerError)
+		return nil
+	}
+	if photo == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+
+	return photo
+}
+
+// servePhotoFile sends a photo from storage, or its thumbnail when size=thumb
+func servePhotoFile(w http.ResponseWriter, r *http.Request, photo *models.GuestPhoto) {
+	key, contentType := photo.FileKey, photo.ContentType
+	if r.URL.Query().Get("size") == "thumb" {
+		key, contentType = photo.ThumbnailKey, "image/jpeg"
+	}
+
+	file, err := storage.Uploads.Open(key)
+	if err != nil {
+		log.Printf("Error opening stored photo %s: %v", key, err)
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	// Files never change under a key, but they are private to signed-in guests
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if r.URL.Query().Get("download") == "true" {
+		w.Header().Set("Content-Disposition", `attachment; filename="photo-`+strconv.FormatInt(photo.ID, 10)+extension(contentType)+`"`)
+	}
+	io.Copy(w, file)
+}
+
+// extension returns the file extension for a photo content type
+func extension(contentType string) string {
+	if contentType == "image/png" {
+		return ".png"
+	}
+	return ".jpg"
+}
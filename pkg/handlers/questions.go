@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/mail"
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleQuestions lets the invitee send the couple a question and read the replies to the
+// questions they sent before
+func HandleQuestions() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			if r.Form.Get("action") != "ask" {
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			notice := "sent"
+			question, err := models.CreateGuestQuestion(email, r.Form.Get("subject"), r.Form.Get("message"), middleware.GetLanguage(r))
+			switch {
+			case errors.Is(err, models.ErrQuestionTooLong):
+				notice = "too_long"
+			case errors.Is(err, models.ErrTooManyQuestions):
+				notice = "too_many"
+			case err != nil:
+				log.Printf("Error saving question from %s: %v", email, err)
+				notice = "invalid"
+			default:
+				// The question is saved either way, so a failed notification only needs logging
+				if err := notifyQuestion(question); err != nil {
+					log.Printf("Error notifying admins of question %d: %v", question.ID, err)
+				}
+			}
+
+			http.Redirect(w, r, "/questions?notice="+notice, http.StatusSeeOther)
+			return
+		}
+
+		questions, err := models.GetInvitationGuestQuestions(email)
+		if err != nil {
+			log.Printf("Error fetching questions for %s: %v", email, err)
+			http.Error(w, "Failed to load your questions", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "sent", "too_long", "too_many", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Questions(questions, notice, r).Render(r.Context(), w)
+	}))
+}
+
+// notifyQuestion emails a new question to the couple, with the guest as the reply address
+func notifyQuestion(question *models.GuestQuestion) error {
+	if len(mail.AdminAddresses) == 0 {
+		return nil
+	}
+
+	body := "From: " + question.InvitationEmail + "\n\n" + question.Message + "\n"
+	if mail.SiteURL != "" {
+		body += "\nReply from the inbox so the guest also sees it on the site: " + mail.SiteURL + "/admin/questions\n"
+	}
+
+	return mail.Outgoing.Send(mail.Message{
+		To:      mail.AdminAddresses,
+		ReplyTo: question.InvitationEmail,
+		Subject: "New question: " + question.Subject,
+		Body:    body,
+	})
+}
+
+// emailReply sends the couple's reply to the guest in the language they asked in, quoting
+// their question
+func emailReply(question *models.GuestQuestion, reply string) error {
+	lang := question.Language
+
+	var body strings.Builder
+	body.WriteString(i18n.T(lang, "questions.email.intro") + "\n\n")
+	body.WriteString(reply + "\n\n")
+	body.WriteString(i18n.T(lang, "questions.email.original") + "\n")
+	for _, line := range strings.Split(question.Message, "\n") {
+		body.WriteString("> " + line + "\n")
+	}
+	if mail.SiteURL != "" {
+		body.WriteString("\n" + i18n.T(lang, "questions.email.link") + " " + mail.SiteURL + "/questions\n")
+	}
+
+	return mail.Outgoing.Send(mail.Message{
+		To:      []string{question.InvitationEmail},
+		ReplyTo: strings.Join(mail.AdminAddresses, ", "),
+		Subject: "Re: " + question.Subject,
+		Body:    body.String(),
+	})
+}
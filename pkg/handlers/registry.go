@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleRegistry shows the gift registry and lets the invitee reserve gifts and pledge to funds.
+// Guests see what is reserved, never by whom.
+func HandleRegistry() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+			if err != nil {
+				http.Redirect(w, r, "/registry?notice=invalid", http.StatusSeeOther)
+				return
+			}
+
+			notice := ""
+			message := r.Form.Get("message")
+			switch r.Form.Get("action") {
+			case "reserve":
+				err = models.ReserveGift(id, email, message)
+				notice = "reserved"
+			case "pledge":
+				amount, parseErr := strconv.Atoi(r.Form.Get("amount"))
+				if parseErr != nil {
+					http.Redirect(w, r, "/registry?notice=invalid", http.StatusSeeOther)
+					return
+				}
+				err = models.PledgeToFund(id, email, amount, message)
+				notice = "pledged"
+			case "cancel":
+				err = models.CancelPledge(id, email)
+				notice = "cancelled"
+			default:
+				http.Error(w, "Unknown action", http.StatusBadRequest)
+				return
+			}
+
+			if errors.Is(err, models.ErrGiftReserved) {
+				notice = "taken"
+			} else if err != nil {
+				log.Printf("Error updating registry for %s: %v", email, err)
+				notice = "invalid"
+			}
+
+			http.Redirect(w, r, "/registry?notice="+notice, http.StatusSeeOther)
+			return
+		}
+
+		items, err := models.GetRegistryItems(true)
+		if err != nil {
+			log.Printf("Error fetching registry: %v", err)
+			http.Error(w, "Failed to load the registry", http.StatusInternalServerError)
+			return
+		}
+
+		pledges, err := models.GetInvitationPledges(email)
+		if err != nil {
+			log.Printf("Error fetching pledges for %s: %v", email, err)
+			http.Error(w, "Failed to load the registry", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "reserved", "pledged", "cancelled", "taken", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Registry(email, items, pledges, notice, r).Render(r.Context(), w)
+	}))
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/mail"
 	"wedding-invite/pkg/middleware"
 	"wedding-invite/pkg/models"
 	"wedding-invite/templates"
@@ -40,8 +44,67 @@ func renderRSVPForm(
 		maxGuests = len(guests) // Default to current count on error
 	}
 
+	// Get the events this invitation may attend
+	events, err := models.GetInvitationEvents(email)
+	if err != nil {
+		log.Printf("Error fetching invitation events: %v", err)
+		http.Error(w, "Failed to load event data", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the menu options guests can currently choose from
+	mealOptions, err := models.GetActiveMealOptions()
+	if err != nil {
+		log.Printf("Error fetching meal options: %v", err)
+		http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the dishes guests can currently pick for each course
+	courseChoices, err := models.GetActiveCourseChoices()
+	if err != nil {
+		log.Printf("Error fetching course choices: %v", err)
+		http.Error(w, "Failed to load menu options", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the shuttle runs, including retired ones guests may still hold seats on
+	shuttleRuns, err := models.GetAllShuttleRuns()
+	if err != nil {
+		log.Printf("Error fetching shuttle runs: %v", err)
+		http.Error(w, "Failed to load shuttle data", http.StatusInternalServerError)
+		return
+	}
+
+	// Check whether infants are left out of the seat limit
+	infantsExempt, err := models.AreInfantsExemptFromLimit()
+	if err != nil {
+		log.Printf("Error fetching seat limit setting: %v", err)
+	}
+
+	// Get the deadline, including any extension granted to this invitation
+	deadline, err := models.GetRSVPDeadline(email)
+	if err != nil {
+		log.Printf("Error fetching RSVP deadline: %v", err)
+		deadline = models.RSVPDeadline()
+	}
+
+	// Get the version of the guest set the form is based on
+	version, err := models.GetRSVPVersion(email)
+	if err != nil {
+		log.Printf("Error fetching RSVP version: %v", err)
+		http.Error(w, "Failed to load guest data", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the latest request for extra seats, so the invitee sees the answer
+	seatRequest, err := models.GetLatestSeatRequest(email)
+	if err != nil {
+		log.Printf("Error fetching seat request: %v", err)
+	}
+
 	// Render RSVP form
-	templates.RSVPForm(email, email, guests, canAddMore, maxGuests, models.MealOptions, successMsg, r).
+	templates.RSVPForm(email, email, guests, canAddMore, maxGuests, infantsExempt, mealOptions, courseChoices, shuttleRuns, events, deadline, version, seatRequest, seatRequestNotice(r), successMsg, r).
 		Render(r.Context(), w)
 }
 
@@ -55,9 +118,21 @@ func HandleRSVP() http.Handler {
 			return
 		}
 
+		// After the deadline the RSVP can only be viewed
+		open, err := models.IsRSVPOpen(session.InvitationEmail)
+		if err != nil {
+			log.Printf("Error checking RSVP deadline: %v", err)
+			http.Error(w, "Failed to load RSVP data", http.StatusInternalServerError)
+			return
+		}
+		if !open {
+			renderRSVPStatus(w, r, session.InvitationEmail, false)
+			return
+		}
+
 		// Check for "Primary Contact" auto-generated entries and remove them
 		// so user starts with a clean form when editing
-		err := models.RemovePrimaryContactGuest(session.InvitationEmail)
+		err = models.RemovePrimaryContactGuest(session.InvitationEmail)
 		if err != nil {
 			log.Printf("Error removing primary contact entry: %v", err)
 			// Continue anyway - non-critical error
@@ -73,6 +148,101 @@ func HandleRSVP() http.Handler {
 	}))
 }
 
+// seatRequestNotice returns the outcome of a seat request submission passed back in the URL
+func seatRequestNotice(r *http.Request) string {
+	switch notice := r.URL.Query().Get("seats"); notice {
+	case "requested", "invalid", "pending_exists":
+		return notice
+	default:
+		return ""
+	}
+}
+
+// HandleSeatRequest lets an invitee ask for more seats than the invitation allows
+func HandleSeatRequest() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		// Requests are only taken while the RSVP can still be changed
+		open, err := models.IsRSVPOpen(session.InvitationEmail)
+		if err != nil {
+			log.Printf("Error checking RSVP deadline: %v", err)
+			http.Error(w, "Failed to process request", http.StatusInternalServerError)
+			return
+		}
+		if !open {
+			http.Error(w, "The RSVP deadline has passed", http.StatusForbidden)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+
+		seats, err := strconv.Atoi(r.Form.Get("seats"))
+		if err != nil || seats < 1 || seats > models.MaxExtraSeats || strings.TrimSpace(r.Form.Get("reason")) == "" {
+			http.Redirect(w, r, "/rsvp?seats=invalid#seat-request", http.StatusSeeOther)
+			return
+		}
+
+		if err := models.CreateSeatRequest(session.InvitationEmail, seats, r.Form.Get("reason")); err != nil {
+			log.Printf("Error creating seat request for %s: %v", session.InvitationEmail, err)
+			http.Redirect(w, r, "/rsvp?seats=pending_exists#seat-request", http.StatusSeeOther)
+			return
+		}
+
+		// The answer is emailed in the language the request was made in
+		if err := models.SetInvitationLanguage(session.InvitationEmail, middleware.GetLanguage(r)); err != nil {
+			log.Printf("Error saving language of %s: %v", session.InvitationEmail, err)
+		}
+
+		http.Redirect(w, r, "/rsvp?seats=requested#seat-request", http.StatusSeeOther)
+	}))
+}
+
+// emailSeatRequestAnswer tells the invitee whether their request for extra seats was approved,
+// with the couple's reply
+func emailSeatRequestAnswer(id int64) error {
+	request, err := models.GetSeatRequest(id)
+	if err != nil {
+		return err
+	}
+	if request == nil {
+		return fmt.Errorf("seat request %d not found", id)
+	}
+
+	lang, err := models.GetInvitationLanguage(request.InvitationEmail)
+	if err != nil {
+		return err
+	}
+
+	seats := strconv.Itoa(request.Seats)
+	var body strings.Builder
+	body.WriteString(strings.Replace(i18n.T(lang, "rsvp.seats.status."+request.Status), "{0}", seats, -1) + "\n")
+	if request.Response.Valid {
+		body.WriteString("\n" + i18n.T(lang, "rsvp.seats.response") + ":\n" + request.Response.String + "\n")
+	}
+	if mail.SiteURL != "" {
+		body.WriteString("\n" + i18n.T(lang, "rsvp.seats.email.link") + " " + mail.SiteURL + "/rsvp\n")
+	}
+
+	return mail.Outgoing.Send(mail.Message{
+		To:      []string{request.InvitationEmail},
+		Subject: i18n.T(lang, "rsvp.seats.email.subject_"+request.Status),
+		Body:    body.String(),
+	})
+}
+
 // HandleSubmitRSVP processes the RSVP form submission
 func HandleSubmitRSVP() http.Handler {
 	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -85,6 +255,19 @@ func HandleSubmitRSVP() http.Handler {
 
 		email := session.InvitationEmail
 
+		// Reject changes once the deadline has passed
+		open, err := models.IsRSVPOpen(email)
+		if err != nil {
+			log.Printf("Error checking RSVP deadline: %v", err)
+			http.Error(w, "Failed to check RSVP deadline", http.StatusInternalServerError)
+			return
+		}
+		if !open {
+			log.Printf("Rejected RSVP submission after the deadline for %s", email)
+			templates.RSVPClosedMessage(r).Render(r.Context(), w)
+			return
+		}
+
 		// Parse form data
 		if err := r.ParseForm(); err != nil {
 			http.Error(w, "Invalid form data", http.StatusBadRequest)
@@ -101,9 +284,52 @@ func HandleSubmitRSVP() http.Handler {
 		// Convert to bool
 		partyAttending := partyAttendingStr == "yes"
 
+		// Reject parties larger than the invitation allows
+		if partyAttending {
+			withinLimit, err := checkSeatLimit(r, email)
+			if err != nil {
+				log.Printf("Error checking seat limit: %v", err)
+				http.Error(w, "Failed to save RSVP", http.StatusInternalServerError)
+				return
+			}
+			if !withinLimit {
+				http.Error(w, "Too many guests for this invitation", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Reject the submission if the guest set changed since the form was loaded,
+		// e.g. because a partner saved from another device
+		claimed := false
+		version, err := strconv.ParseInt(r.Form.Get("rsvp_version"), 10, 64)
+		if err == nil {
+			claimed, err = models.ClaimRSVPVersion(email, version)
+			if err != nil {
+				log.Printf("Error claiming RSVP version: %v", err)
+				http.Error(w, "Failed to save RSVP", http.StatusInternalServerError)
+				return
+			}
+		}
+		if !claimed {
+			log.Printf("Rejected stale RSVP submission for %s", email)
+			currentGuests, err := models.GetGuestsByInvitation(email)
+			if err != nil {
+				log.Printf("Error fetching guests: %v", err)
+			}
+			templates.RSVPConflict(currentGuests, r).Render(r.Context(), w)
+			return
+		}
+
 		// Process all guests from form
 		guestIDs := r.Form["guest_ids[]"]
 
+		// Shuttle runs that filled up before a guest's seat could be booked
+		fullShuttles := make(map[int64]bool)
+
+		// Dishes that could not be saved, listed with their guests for the invitee to choose again
+		var droppedCourses []string
+		lang := middleware.GetLanguage(r)
+
 		// First, find all existing guests in database regardless of whether guestIDs are present
 		existingGuests, err := models.GetGuestsByInvitation(email)
 		if err != nil {
@@ -139,6 +365,28 @@ func HandleSubmitRSVP() http.Handler {
 							err,
 						)
 					}
+
+					// Clear allergens and courses along with the meal preference
+					err = models.SetGuestAllergens(guest.ID, email, nil)
+					if err != nil {
+						log.Printf("Error clearing allergens for guest %d: %v", guest.ID, err)
+					}
+					_, err = models.SetGuestCourses(guest.ID, email, "", nil)
+					if err != nil {
+						log.Printf("Error clearing courses for guest %d: %v", guest.ID, err)
+					}
+
+					// Guests who stay home need no assistance
+					err = models.SetGuestAccessibility(guest.ID, email, nil, "")
+					if err != nil {
+						log.Printf("Error clearing accessibility needs for guest %d: %v", guest.ID, err)
+					}
+
+					// Free their shuttle seats for others
+					_, err = models.SetGuestShuttles(guest.ID, email, nil)
+					if err != nil {
+						log.Printf("Error clearing shuttle seats for guest %d: %v", guest.ID, err)
+					}
 				}
 			}
 
@@ -175,8 +423,15 @@ func HandleSubmitRSVP() http.Handler {
 				log.Printf("Error removing primary contact entries: %v", err)
 			}
 
-			// Delete any guests that were removed in the UI
+			// Delete any guests that were removed in the UI; guests invited by name always stay
+			namedGuests := make(map[int64]bool)
+			guestNames := make(map[int64]string)
 			for _, guest := range existingGuests {
+				guestNames[guest.ID] = guest.Name
+				if guest.Named {
+					namedGuests[guest.ID] = true
+					continue
+				}
 				if !existingGuestMap[guest.ID] {
 					// This guest is in DB but not in the form, so delete it
 					err := models.DeleteGuest(guest.ID, email)
@@ -198,6 +453,18 @@ func HandleSubmitRSVP() http.Handler {
 				guestName := r.Form.Get(fmt.Sprintf("guest_name_%d", guestID))
 				mealPreference := r.Form.Get(fmt.Sprintf("guest_meal_%d", guestID))
 				dietaryRestrictions := r.Form.Get(fmt.Sprintf("guest_dietary_%d", guestID))
+				allergens := r.Form[fmt.Sprintf("guest_allergens_%d", guestID)]
+				accessibilityNeeds := r.Form[fmt.Sprintf("guest_accessibility_%d", guestID)]
+				accessibilityNotes := r.Form.Get(fmt.Sprintf("guest_accessibility_notes_%d", guestID))
+				shuttles := parseGuestShuttles(r, guestID)
+				courses := parseGuestCourses(r, guestID)
+				ageCategory, age := parseGuestAge(r, guestID)
+
+				// Only children may pick the children's menu
+				if !models.MealAllowedForAge(mealPreference, ageCategory) {
+					log.Printf("Ignoring meal %q for %s guest %d", mealPreference, ageCategory, guestID)
+					mealPreference = ""
+				}
 
 				// If the ID is negative, this is a temporary guest that needs to be created
 				if guestID < 0 {
@@ -208,6 +475,12 @@ func HandleSubmitRSVP() http.Handler {
 						continue
 					}
 
+					// Record the new guest's age
+					err = models.SetGuestAge(newGuestID, email, ageCategory, age)
+					if err != nil {
+						log.Printf("Error updating age for new guest %d: %v", newGuestID, err)
+					}
+
 					// Update the new guest's RSVP status
 					err = models.UpdateGuestRSVP(
 						newGuestID,
@@ -218,15 +491,50 @@ func HandleSubmitRSVP() http.Handler {
 					if err != nil {
 						log.Printf("Error updating RSVP for new guest %d: %v", newGuestID, err)
 					}
+
+					// Record the new guest's allergens
+					err = models.SetGuestAllergens(newGuestID, email, allergens)
+					if err != nil {
+						log.Printf("Error updating allergens for new guest %d: %v", newGuestID, err)
+					}
+
+					// Record the new guest's course choices
+					dropped, err := models.SetGuestCourses(newGuestID, email, mealPreference, courses)
+					if err != nil {
+						log.Printf("Error updating courses for new guest %d: %v", newGuestID, err)
+					}
+					droppedCourses = append(droppedCourses, describeCourses(lang, guestName, dropped)...)
+
+					// Record the assistance the new guest needs
+					err = models.SetGuestAccessibility(newGuestID, email, accessibilityNeeds, accessibilityNotes)
+					if err != nil {
+						log.Printf("Error updating accessibility needs for new guest %d: %v", newGuestID, err)
+					}
+
+					// Book the new guest's shuttle seats
+					full, err := models.SetGuestShuttles(newGuestID, email, shuttles)
+					if err != nil {
+						log.Printf("Error booking shuttle seats for new guest %d: %v", newGuestID, err)
+					}
+					for _, runID := range full {
+						fullShuttles[runID] = true
+					}
 				} else {
 					// This is an existing guest from the database
 
-					// Update guest name if needed
-					if guestName != "" {
+					// Update guest name if needed; names chosen by an admin are fixed
+					if guestName != "" && !namedGuests[guestID] {
 						err := models.UpdateGuestName(guestID, email, guestName)
 						if err != nil {
 							log.Printf("Error updating guest name for guest %d: %v", guestID, err)
 						}
+						guestNames[guestID] = guestName
+					}
+
+					// Update guest age
+					err = models.SetGuestAge(guestID, email, ageCategory, age)
+					if err != nil {
+						log.Printf("Error updating age for guest %d: %v", guestID, err)
 					}
 
 					// Update guest RSVP status
@@ -239,15 +547,292 @@ func HandleSubmitRSVP() http.Handler {
 					if err != nil {
 						log.Printf("Error updating RSVP for guest %d: %v", guestID, err)
 					}
+
+					// Update guest allergens
+					err = models.SetGuestAllergens(guestID, email, allergens)
+					if err != nil {
+						log.Printf("Error updating allergens for guest %d: %v", guestID, err)
+					}
+
+					// Update guest course choices
+					dropped, err := models.SetGuestCourses(guestID, email, mealPreference, courses)
+					if err != nil {
+						log.Printf("Error updating courses for guest %d: %v", guestID, err)
+					}
+					droppedCourses = append(droppedCourses, describeCourses(lang, guestNames[guestID], dropped)...)
+
+					// Update the assistance the guest needs
+					err = models.SetGuestAccessibility(guestID, email, accessibilityNeeds, accessibilityNotes)
+					if err != nil {
+						log.Printf("Error updating accessibility needs for guest %d: %v", guestID, err)
+					}
+
+					// Update guest shuttle seats
+					full, err := models.SetGuestShuttles(guestID, email, shuttles)
+					if err != nil {
+						log.Printf("Error booking shuttle seats for guest %d: %v", guestID, err)
+					}
+					for _, runID := range full {
+						fullShuttles[runID] = true
+					}
+				}
+			}
+		}
+
+		// Promotions from the waitlist are emailed in the language the RSVP was made in
+		if err := models.SetInvitationLanguage(email, middleware.GetLanguage(r)); err != nil {
+			log.Printf("Error saving language of %s: %v", email, err)
+		}
+
+		// Give accepted guests a place at the venue, or put them on the waitlist. The answers are
+		// already saved, so a failure is only logged; the next allocation will place these guests.
+		if err := allocateVenuePlaces(); err != nil {
+			log.Printf("Error allocating venue places: %v", err)
+		}
+
+		// Keep an immutable snapshot of the party as submitted, including the places it was given
+		if err := models.RecordRSVPRevision(email); err != nil {
+			log.Printf("Error recording RSVP revision for %s: %v", email, err)
+		}
+
+		// Tell the invitee which of their guests are still waiting for a place
+		var waitlisted []models.Guest
+		guests, err := models.GetGuestsByInvitation(email)
+		if err != nil {
+			log.Printf("Error fetching guests: %v", err)
+		}
+		for _, guest := range guests {
+			if guest.IsWaitlisted() {
+				waitlisted = append(waitlisted, guest)
+			}
+		}
+
+		// Tell the invitee which shuttles had no seats left
+		var fullRuns []models.ShuttleRun
+		if len(fullShuttles) > 0 {
+			runs, err := models.GetAllShuttleRuns()
+			if err != nil {
+				log.Printf("Error fetching shuttle runs: %v", err)
+			}
+			for _, run := range runs {
+				if fullShuttles[run.ID] {
+					fullRuns = append(fullRuns, run)
 				}
 			}
 		}
 
 		// Return success message with the email address
-		templates.SuccessMessage(email, r).Render(r.Context(), w)
+		templates.SuccessMessage(email, waitlisted, fullRuns, droppedCourses, r).Render(r.Context(), w)
 	}))
 }
 
+// allocateVenuePlaces hands out the venue's places and emails the news of every promotion from
+// the waitlist to the promoted guests' invitations and to the couple. The places are taken even
+// when an email fails, so failed notifications are only logged.
+func allocateVenuePlaces() error {
+	promoted, err := models.AllocateVenuePlaces()
+	if err != nil {
+		return err
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+
+	byInvitation := make(map[string][]models.Guest)
+	var invitations []string
+	for _, guest := range promoted {
+		log.Printf("Promoted guest %d (%s) of %s from the waitlist", guest.ID, guest.Name, guest.InvitationEmail)
+		if _, ok := byInvitation[guest.InvitationEmail]; !ok {
+			invitations = append(invitations, guest.InvitationEmail)
+		}
+		byInvitation[guest.InvitationEmail] = append(byInvitation[guest.InvitationEmail], guest)
+	}
+
+	for _, email := range invitations {
+		if err := emailPromotion(email, byInvitation[email]); err != nil {
+			log.Printf("Error emailing waitlist promotion to %s: %v", email, err)
+		}
+	}
+	if err := notifyPromotions(promoted); err != nil {
+		log.Printf("Error notifying admins of waitlist promotions: %v", err)
+	}
+
+	return nil
+}
+
+// emailPromotion tells an invitation which of its guests got a place at the venue
+func emailPromotion(email string, guests []models.Guest) error {
+	lang, err := models.GetInvitationLanguage(email)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(guests))
+	for i, guest := range guests {
+		names[i] = guest.Name
+	}
+
+	body := strings.Replace(i18n.T(lang, "rsvp.waitlist.promoted"), "{0}", strings.Join(names, ", "), -1) + "\n"
+	if mail.SiteURL != "" {
+		body += "\n" + i18n.T(lang, "rsvp.waitlist.email.link") + " " + mail.SiteURL + "/rsvp/status\n"
+	}
+
+	return mail.Outgoing.Send(mail.Message{
+		To:      []string{email},
+		Subject: i18n.T(lang, "rsvp.waitlist.email.subject"),
+		Body:    body,
+	})
+}
+
+// notifyPromotions emails the couple the guests who moved from the waitlist to a place
+func notifyPromotions(guests []models.Guest) error {
+	if len(mail.AdminAddresses) == 0 {
+		return nil
+	}
+
+	var body strings.Builder
+	body.WriteString("These guests moved from the waitlist to a place at the venue:\n\n")
+	for _, guest := range guests {
+		body.WriteString("- " + guest.Name + " (" + guest.InvitationEmail + ")\n")
+	}
+	if mail.SiteURL != "" {
+		body.WriteString("\nSee the waitlist: " + mail.SiteURL + "/admin/venue\n")
+	}
+
+	return mail.Outgoing.Send(mail.Message{
+		To:      mail.AdminAddresses,
+		Subject: fmt.Sprintf("%d guest(s) promoted from the waitlist", len(guests)),
+		Body:    body.String(),
+	})
+}
+
+// describeCourses names the guest's courses whose dish could not be saved, in the invitee's language
+func describeCourses(lang, guestName string, courses []string) []string {
+	described := make([]string, 0, len(courses))
+	for _, course := range courses {
+		described = append(described, fmt.Sprintf("%s (%s)", guestName, i18n.T(lang, "courses."+course)))
+	}
+	return described
+}
+
+// parseGuestAge reads the age category and optional age from the guest's form fields
+func parseGuestAge(r *http.Request, guestID int64) (string, sql.NullInt64) {
+	category := r.Form.Get(fmt.Sprintf("guest_age_category_%d", guestID))
+	if !models.IsAgeCategory(category) {
+		category = models.AgeAdult
+	}
+
+	var age sql.NullInt64
+	value := r.Form.Get(fmt.Sprintf("guest_age_%d", guestID))
+	if value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 || parsed > models.MaxGuestAge {
+			log.Printf("Invalid age %s for guest %d", value, guestID)
+		} else {
+			age = sql.NullInt64{Int64: parsed, Valid: true}
+		}
+	}
+
+	return category, age
+}
+
+// checkSeatLimit reports whether the submitted party fits the invitation's max_guests limit
+func checkSeatLimit(r *http.Request, email string) (bool, error) {
+	maxGuests, err := models.GetMaxGuestCount(email)
+	if err != nil {
+		return false, err
+	}
+
+	infantsExempt, err := models.AreInfantsExemptFromLimit()
+	if err != nil {
+		return false, err
+	}
+
+	seats := 0
+	for _, guestIDStr := range r.Form["guest_ids[]"] {
+		guestID, err := strconv.ParseInt(guestIDStr, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		category, _ := parseGuestAge(r, guestID)
+		if infantsExempt && category == models.AgeInfant {
+			continue
+		}
+		seats++
+	}
+
+	return seats <= maxGuests, nil
+}
+
+// parseGuestCourses reads the dish chosen for each course from the guest's form fields
+func parseGuestCourses(r *http.Request, guestID int64) map[string]int64 {
+	courses := make(map[string]int64)
+	for _, course := range models.Courses {
+		value := r.Form.Get(fmt.Sprintf("guest_course_%s_%d", course, guestID))
+		if value == "" {
+			continue
+		}
+
+		choiceID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Printf("Invalid %s choice %s for guest %d", course, value, guestID)
+			continue
+		}
+		courses[course] = choiceID
+	}
+	return courses
+}
+
+// parseGuestShuttles reads the shuttle runs ticked in the guest's form fields
+func parseGuestShuttles(r *http.Request, guestID int64) []int64 {
+	var runIDs []int64
+	for _, value := range r.Form[fmt.Sprintf("guest_shuttles_%d", guestID)] {
+		runID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Printf("Invalid shuttle run %s for guest %d", value, guestID)
+			continue
+		}
+		runIDs = append(runIDs, runID)
+	}
+	return runIDs
+}
+
+// renderRSVPStatus is a helper function to render the RSVP status page
+func renderRSVPStatus(w http.ResponseWriter, r *http.Request, email string, rsvpOpen bool) {
+	// Get guest data
+	guests, err := models.GetGuestsByInvitation(email)
+	if err != nil {
+		log.Printf("Error fetching guests: %v", err)
+		http.Error(w, "Failed to load guest data", http.StatusInternalServerError)
+		return
+	}
+
+	// Check if we have only the "Primary Contact" auto entry
+	hasPrimaryContactOnly := false
+	if len(guests) == 1 && guests[0].Name == "Primary Contact" {
+		hasPrimaryContactOnly = true
+	}
+
+	// Get the latest revision to show what changed last
+	var lastRevision *models.RevisionEntry
+	timeline, err := models.GetRSVPTimeline(email)
+	if err != nil {
+		log.Printf("Error fetching RSVP history: %v", err)
+	} else if len(timeline) > 0 {
+		lastRevision = &timeline[0]
+	}
+
+	// Get the shuttle runs to show the guests' seats
+	shuttleRuns, err := models.GetAllShuttleRuns()
+	if err != nil {
+		log.Printf("Error fetching shuttle runs: %v", err)
+	}
+
+	// Render RSVP status page with the flag
+	templates.RSVPStatus(email, guests, shuttleRuns, hasPrimaryContactOnly, rsvpOpen, lastRevision, r).Render(r.Context(), w)
+}
+
 // HandleRSVPStatus shows the current RSVP status
 func HandleRSVPStatus() http.Handler {
 	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -258,24 +843,13 @@ func HandleRSVPStatus() http.Handler {
 			return
 		}
 
-		email := session.InvitationEmail
-
-		// Get guest data
-		guests, err := models.GetGuestsByInvitation(email)
+		// Only offer editing while RSVPs are open
+		open, err := models.IsRSVPOpen(session.InvitationEmail)
 		if err != nil {
-			log.Printf("Error fetching guests: %v", err)
-			http.Error(w, "Failed to load guest data", http.StatusInternalServerError)
-			return
-		}
-
-		// Check if we have only the "Primary Contact" auto entry
-		hasPrimaryContactOnly := false
-		if len(guests) == 1 && guests[0].Name == "Primary Contact" {
-			hasPrimaryContactOnly = true
+			log.Printf("Error checking RSVP deadline: %v", err)
+			open = false
 		}
 
-		// Render RSVP status page with the flag
-		templates.RSVPStatus(email, guests, hasPrimaryContactOnly, r).Render(r.Context(), w)
+		renderRSVPStatus(w, r, session.InvitationEmail, open)
 	}))
 }
-
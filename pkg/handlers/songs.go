@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleSongs lets the invitee request songs for the party and vote for other guests' requests
+func HandleSongs() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Get session from context
+		session := middleware.GetSessionFromContext(r)
+		if session == nil {
+			http.Redirect(w, r, "/?error=auth_required", http.StatusFound)
+			return
+		}
+
+		email := session.InvitationEmail
+
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "Invalid form data", http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			notice := ""
+			action := r.Form.Get("action")
+			if action == "request" {
+				err = models.RequestSong(email, r.Form.Get("title"), r.Form.Get("artist"), r.Form.Get("dedication"))
+				notice = "requested"
+			} else {
+				id, parseErr := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+				if parseErr != nil {
+					http.Error(w, "Invalid song", http.StatusBadRequest)
+					return
+				}
+
+				switch action {
+				case "vote", "unvote":
+					err = models.VoteForSong(id, email, action == "vote")
+				case "withdraw":
+					err = models.DeleteSongRequest(id, email)
+				default:
+					http.Error(w, "Unknown action", http.StatusBadRequest)
+					return
+				}
+			}
+
+			if errors.Is(err, models.ErrSongLimit) {
+				notice = "limit"
+			} else if err != nil {
+				log.Printf("Error updating song requests for %s: %v", email, err)
+				notice = "invalid"
+			}
+
+			redirect := "/songs"
+			if notice != "" {
+				redirect += "?notice=" + notice
+			}
+			http.Redirect(w, r, redirect, http.StatusSeeOther)
+			return
+		}
+
+		songs, err := models.GetSongRequests(email)
+		if err != nil {
+			log.Printf("Error fetching song requests: %v", err)
+			http.Error(w, "Failed to load song requests", http.StatusInternalServerError)
+			return
+		}
+
+		notice := ""
+		switch r.URL.Query().Get("notice") {
+		case "requested", "limit", "invalid":
+			notice = r.URL.Query().Get("notice")
+		}
+
+		templates.Songs(email, songs, notice, r).Render(r.Context(), w)
+	}))
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleStory shows the couple's "Our Story" timeline
+func HandleStory() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries, err := models.GetStoryEntries()
+		if err != nil {
+			log.Printf("Error fetching story: %v", err)
+			http.Error(w, "Failed to load our story", http.StatusInternalServerError)
+			return
+		}
+
+		templates.Story(entries, r).Render(r.Context(), w)
+	}))
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"wedding-invite/pkg/middleware"
+	"wedding-invite/pkg/models"
+	"wedding-invite/templates"
+)
+
+// HandleTravel shows the venues, hotels, airport and transport on a map with directions
+func HandleTravel() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		places, err := models.GetTravelPlaces()
+		if err != nil {
+			log.Printf("Error fetching travel places: %v", err)
+			http.Error(w, "Failed to load travel information", http.StatusInternalServerError)
+			return
+		}
+
+		mapVersion := ""
+		if len(places) > 0 {
+			mapVersion = strings.TrimSuffix(path.Base(models.TravelMapKey(places)), ".jpg")
+		}
+
+		templates.Travel(places, mapVersion, r).Render(r.Context(), w)
+	}))
+}
+
+// HandleTravelMap serves the static map of the travel places. The page links to it with the
+// map's version, so browsers may keep a version for as long as they like.
+func HandleTravelMap() http.Handler {
+	return middleware.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		places, err := models.GetTravelPlaces()
+		if err != nil {
+			log.Printf("Error fetching travel places: %v", err)
+			http.Error(w, "Failed to load map", http.StatusInternalServerError)
+			return
+		}
+		if len(places) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		key := models.TravelMapKey(places)
+		data, err := models.GetTravelMap(places, key)
+		if err != nil {
+			log.Printf("Error rendering travel map: %v", err)
+			http.Error(w, "Failed to render map", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		if r.URL.Query().Get("v") == strings.TrimSuffix(path.Base(key), ".jpg") {
+			w.Header().Set("Cache-Control", "private, max-age=86400")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		w.Write(data)
+	}))
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // DefaultLanguage is the fallback language
@@ -102,3 +103,18 @@ func AvailableLanguages() []string {
 	}
 	return languages
 }
+
+// FormatDate formats a date with the month name in the specified language,
+// e.g. "August 15, 2025" in English and "15 august 2025" in Romanian
+func FormatDate(lang string, t time.Time) string {
+	month := T(lang, fmt.Sprintf("months.%d", int(t.Month())))
+	if lang == "en" {
+		return fmt.Sprintf("%s %d, %d", month, t.Day(), t.Year())
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), month, t.Year())
+}
+
+// FormatDateTime formats a date like FormatDate followed by the 24-hour time
+func FormatDateTime(lang string, t time.Time) string {
+	return fmt.Sprintf("%s, %s", FormatDate(lang, t), t.Format("15:04"))
+}
@@ -0,0 +1,214 @@
+// Package imaging prepares uploaded photos in pure Go: it strips location metadata, decodes
+// JPEG and PNG files, applies their EXIF orientation and produces resized JPEG copies.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels caps the size of images decoded, so a small file cannot expand into more memory
+// than the server has
+const MaxPixels = 40_000_000
+
+var (
+	// ErrUnsupported is returned for files that are not JPEG or PNG images
+	ErrUnsupported = errors.New("unsupported image type")
+	// ErrTooManyPixels is returned for images larger than MaxPixels
+	ErrTooManyPixels = errors.New("image has too many pixels")
+)
+
+// Sanitize checks that data is a JPEG or PNG image and removes the metadata that can reveal
+// where it was taken. It returns the cleaned file, its content type and its EXIF orientation.
+func Sanitize(data []byte) ([]byte, string, int, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg":
+		cleaned, orientation, err := stripJPEGLocation(data)
+		if err != nil {
+			return nil, "", 0, ErrUnsupported
+		}
+		return cleaned, contentType, orientation, nil
+	case "image/png":
+		cleaned, err := stripPNGMetadata(data)
+		if err != nil {
+			return nil, "", 0, ErrUnsupported
+		}
+		return cleaned, contentType, 1, nil
+	default:
+		return nil, "", 0, ErrUnsupported
+	}
+}
+
+// Size returns the width and height of a JPEG or PNG image once turned upright
+func Size(data []byte, orientation int) (int, int, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return 0, 0, ErrUnsupported
+	}
+	if orientation >= 5 {
+		return config.Height, config.Width, nil
+	}
+	return config.Width, config.Height, nil
+}
+
+// Decode decodes a JPEG or PNG image, scales it down to fit in a size by size square and
+// turns it upright according to its EXIF orientation. Scaling first keeps the rotation cheap.
+func Decode(data []byte, orientation, size int) (image.Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrUnsupported
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	var img image.Image
+	if format == "jpeg" {
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	} else {
+		img, err = png.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	return orient(Fit(img, size), orientation), nil
+}
+
+// Fit scales an image down by area averaging so it fits in a size by size square.
+// Smaller images are returned as they are.
+func Fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= size && srcH <= size {
+		return img
+	}
+
+	dstW, dstH := size, srcH*size/srcW
+	if srcH > srcW {
+		dstW, dstH = srcW*size/srcH, size
+	}
+	dstW, dstH = max(dstW, 1), max(dstH, 1)
+
+	pixel := pixelReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0, y1 := y*srcH/dstH, max((y+1)*srcH/dstH, y*srcH/dstH+1)
+		for x := 0; x < dstW; x++ {
+			x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+
+			var r, g, b, a uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := pixel(bounds.Min.X+sx, bounds.Min.Y+sy)
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+				}
+			}
+			n := uint64((y1 - y0) * (x1 - x0))
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG encodes an image as a JPEG, putting transparent areas on a white background
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(bounds)
+	pixel := pixelReader(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := pixel(x, y)
+			i := flat.PixOffset(x, y)
+			flat.Pix[i] = uint8((r + 0xffff - a) >> 8)
+			flat.Pix[i+1] = uint8((g + 0xffff - a) >> 8)
+			flat.Pix[i+2] = uint8((b + 0xffff - a) >> 8)
+			flat.Pix[i+3] = 0xff
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pixelReader returns a function reading alpha-premultiplied 16-bit colour values, with fast
+// paths for the image types the decoders produce
+func pixelReader(img image.Image) func(x, y int) (uint32, uint32, uint32, uint32) {
+	switch src := img.(type) {
+	case *image.YCbCr:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			yi, ci := src.YOffset(x, y), src.COffset(x, y)
+			r, g, b := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			return uint32(r) * 0x101, uint32(g) * 0x101, uint32(b) * 0x101, 0xffff
+		}
+	case *image.RGBA:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			i := src.PixOffset(x, y)
+			return uint32(src.Pix[i]) * 0x101, uint32(src.Pix[i+1]) * 0x101, uint32(src.Pix[i+2]) * 0x101, uint32(src.Pix[i+3]) * 0x101
+		}
+	default:
+		return func(x, y int) (uint32, uint32, uint32, uint32) {
+			return img.At(x, y).RGBA()
+		}
+	}
+}
+
+// orient turns an image upright according to an EXIF orientation between 1 and 8
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	pixel := pixelReader(img)
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			r, g, b, a := pixel(bounds.Min.X+x, bounds.Min.Y+y)
+			i := dst.PixOffset(dx, dy)
+			dst.Pix[i] = uint8(r >> 8)
+			dst.Pix[i+1] = uint8(g >> 8)
+			dst.Pix[i+2] = uint8(b >> 8)
+			dst.Pix[i+3] = uint8(a >> 8)
+		}
+	}
+
+	return dst
+}
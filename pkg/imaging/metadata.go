@@ -0,0 +1,186 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngHeader  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// TIFF tags read or scrubbed in EXIF data
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+// stripJPEGLocation removes the GPS data from a JPEG's EXIF block and drops its XMP block,
+// which can repeat the location. It returns the cleaned file and the EXIF orientation.
+// Everything from the start of the image data on is copied unchanged.
+func stripJPEGLocation(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errors.New("not a JPEG file")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	orientation := 1
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 0, errors.New("malformed JPEG segment")
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan: the rest is image data
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, errors.New("truncated JPEG segment")
+		}
+		segment := data[pos:end]
+		payload := segment[4:]
+		pos = end
+
+		if marker == 0xE1 && bytes.HasPrefix(payload, xmpHeader) {
+			continue
+		}
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			cleaned := append([]byte(nil), segment...)
+			o, err := scrubGPS(cleaned[4+len(exifHeader):])
+			if err != nil {
+				// Unreadable EXIF data might still hold a location; leave it out
+				continue
+			}
+			orientation = o
+			segment = cleaned
+		}
+		out = append(out, segment...)
+	}
+
+	return append(out, data[pos:]...), orientation, nil
+}
+
+// scrubGPS zeroes the GPS directory of a TIFF structure in place, along with the values it
+// points to, and returns the orientation recorded in the first directory
+func scrubGPS(tiff []byte) (int, error) {
+	if len(tiff) < 8 {
+		return 0, errors.New("short TIFF header")
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, errors.New("unknown TIFF byte order")
+	}
+
+	entries := func(offset uint32) (int, int, error) {
+		start := int(offset)
+		if start < 8 || start+2 > len(tiff) {
+			return 0, 0, errors.New("TIFF directory out of range")
+		}
+		count := int(order.Uint16(tiff[start:]))
+		if start+2+count*12 > len(tiff) {
+			return 0, 0, errors.New("TIFF directory out of range")
+		}
+		return start, count, nil
+	}
+
+	ifd0, count, err := entries(order.Uint32(tiff[4:]))
+	if err != nil {
+		return 0, err
+	}
+
+	orientation := 1
+	for i := 0; i < count; i++ {
+		entry := tiff[ifd0+2+i*12:]
+		switch order.Uint16(entry) {
+		case tagOrientation:
+			if o := int(order.Uint16(entry[8:])); o >= 1 && o <= 8 {
+				orientation = o
+			}
+		case tagGPSInfo:
+			gps, gpsCount, err := entries(order.Uint32(entry[8:]))
+			if err != nil {
+				return 0, err
+			}
+			for j := 0; j < gpsCount; j++ {
+				gpsEntry := tiff[gps+2+j*12 : gps+2+(j+1)*12]
+				size := tiffTypeSize(order.Uint16(gpsEntry[2:])) * int(order.Uint32(gpsEntry[4:]))
+				if size > 4 {
+					offset := int(order.Uint32(gpsEntry[8:]))
+					if offset < 0 || offset+size > len(tiff) {
+						return 0, errors.New("GPS value out of range")
+					}
+					clear(tiff[offset : offset+size])
+				}
+				clear(gpsEntry)
+			}
+			// An empty directory whose next-directory link is now zero
+			order.PutUint16(tiff[gps:], 0)
+		}
+	}
+
+	return orientation, nil
+}
+
+// tiffTypeSize returns the size in bytes of one value of a TIFF field type
+func tiffTypeSize(fieldType uint16) int {
+	switch fieldType {
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	default:
+		return 1
+	}
+}
+
+// stripPNGMetadata drops the EXIF and text chunks of a PNG file, where cameras and editors
+// record locations
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngHeader) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngHeader...)
+	pos := len(pngHeader)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	return out, nil
+}
@@ -0,0 +1,376 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// gpsMarker is written into the fixtures' GPS coordinates, so tests can tell whether any of
+// the location survived
+var gpsMarker = []byte{0x5A, 0x17, 0xC0, 0x0D}
+
+// tiffEntry is one field of a TIFF directory; values of four bytes or less are stored inline
+type tiffEntry struct {
+	tag, fieldType uint16
+	count          uint32
+	value          []byte
+}
+
+// buildTIFF writes an EXIF TIFF structure with an orientation and a GPS directory holding
+// a latitude reference and a latitude made of three rationals
+func buildTIFF(order binary.ByteOrder, orientation uint16) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	u16 := func(v uint16) []byte { b := make([]byte, 2); order.PutUint16(b, v); return b }
+	u32 := func(v uint32) []byte { b := make([]byte, 4); order.PutUint32(b, v); return b }
+	buf.Write(u16(42))
+	buf.Write(u32(8))
+
+	// IFD0 at 8 with two entries ends at 8+2+2*12+4 = 38, where the GPS directory starts
+	const gpsOffset = 38
+	// The GPS directory has two entries, so its out-of-line values start at 38+2+2*12+4 = 68
+	const latitudeOffset = 68
+
+	orientationValue := append(u16(orientation), 0, 0)
+	writeIFD := func(entries []tiffEntry) {
+		buf.Write(u16(uint16(len(entries))))
+		for _, e := range entries {
+			buf.Write(u16(e.tag))
+			buf.Write(u16(e.fieldType))
+			buf.Write(u32(e.count))
+			buf.Write(e.value)
+		}
+		buf.Write(u32(0))
+	}
+	writeIFD([]tiffEntry{
+		{tagOrientation, 3, 1, orientationValue},
+		{tagGPSInfo, 4, 1, u32(gpsOffset)},
+	})
+	writeIFD([]tiffEntry{
+		{0x0001, 2, 2, []byte{'N', 0, 0, 0}},
+		{0x0002, 5, 3, u32(latitudeOffset)},
+	})
+
+	// Three rationals: degrees carry the marker, minutes and seconds are ordinary
+	buf.Write(gpsMarker)
+	buf.Write(u32(1))
+	buf.Write(u32(26))
+	buf.Write(u32(1))
+	buf.Write(u32(3050))
+	buf.Write(u32(100))
+
+	return buf.Bytes()
+}
+
+// jpegSegment wraps a payload in a JPEG marker segment
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// testJPEG encodes a small image and inserts the given segments right after its start marker
+func testJPEG(t *testing.T, segments ...[]byte) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for x := 0; x < 16; x++ {
+		img.Set(x, 3, color.RGBA{0xff, 0, 0, 0xff})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	out := append([]byte(nil), encoded[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, encoded[2:]...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return jpegSegment(0xE1, append(append([]byte(nil), exifHeader...), tiff...))
+}
+
+func xmpSegment() []byte {
+	return jpegSegment(0xE1, append(append([]byte(nil), xmpHeader...),
+		[]byte(`<x:xmpmeta><rdf:Description exif:GPSLatitude="44,26.5N"/></x:xmpmeta>`)...))
+}
+
+func TestStripJPEGLocation(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		order binary.ByteOrder
+	}{
+		{"little endian", binary.LittleEndian},
+		{"big endian", binary.BigEndian},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tiff := buildTIFF(tc.order, 6)
+			data := testJPEG(t, exifSegment(tiff), xmpSegment())
+			if !bytes.Contains(data, gpsMarker) {
+				t.Fatal("fixture does not contain the GPS marker")
+			}
+
+			cleaned, orientation, err := stripJPEGLocation(data)
+			if err != nil {
+				t.Fatalf("stripJPEGLocation: %v", err)
+			}
+			if orientation != 6 {
+				t.Errorf("orientation = %d, want 6", orientation)
+			}
+			if bytes.Contains(cleaned, gpsMarker) {
+				t.Error("GPS coordinates survived")
+			}
+			if bytes.Contains(cleaned, []byte("GPSLatitude")) || bytes.Contains(cleaned, xmpHeader) {
+				t.Error("XMP block survived")
+			}
+			if !bytes.Contains(cleaned, exifHeader) {
+				t.Error("EXIF block was dropped instead of scrubbed")
+			}
+
+			// The GPS directory is left empty
+			start := bytes.Index(cleaned, exifHeader) + len(exifHeader)
+			if count := tc.order.Uint16(cleaned[start+38:]); count != 0 {
+				t.Errorf("GPS directory still has %d entries", count)
+			}
+
+			if _, err := jpeg.Decode(bytes.NewReader(cleaned)); err != nil {
+				t.Errorf("cleaned JPEG does not decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestStripJPEGLocationKeepsOtherSegments(t *testing.T) {
+	comment := jpegSegment(0xFE, []byte("taken at the party"))
+	data := testJPEG(t, comment)
+
+	cleaned, orientation, err := stripJPEGLocation(data)
+	if err != nil {
+		t.Fatalf("stripJPEGLocation: %v", err)
+	}
+	if orientation != 1 {
+		t.Errorf("orientation = %d, want 1", orientation)
+	}
+	if !bytes.Equal(cleaned, data) {
+		t.Error("a JPEG without location data was changed")
+	}
+}
+
+func TestStripJPEGLocationMalformedEXIF(t *testing.T) {
+	valid := buildTIFF(binary.LittleEndian, 1)
+	patched := func(at int, value ...byte) []byte {
+		tiff := append([]byte(nil), valid...)
+		copy(tiff[at:], value)
+		return tiff
+	}
+
+	for _, tc := range []struct {
+		name string
+		tiff []byte
+	}{
+		{"short header", valid[:6]},
+		{"unknown byte order", patched(0, 'X', 'X')},
+		{"first directory out of range", patched(4, 0xFF, 0xFF, 0xFF, 0x7F)},
+		{"first directory before the header", patched(4, 2, 0, 0, 0)},
+		{"first directory too long", patched(8, 0xFF, 0xFF)},
+		{"GPS directory out of range", patched(10+12+8, 0xF0, 0xFF, 0xFF, 0xFF)},
+		{"GPS directory too long", patched(38, 0xFF, 0x7F)},
+		{"GPS value out of range", patched(38+2+12+8, 0xF0, 0xFF, 0xFF, 0xFF)},
+		{"GPS value count overflows", patched(38+2+12+4, 0xFF, 0xFF, 0xFF, 0xFF)},
+		{"truncated GPS values", valid[:len(valid)-8]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := testJPEG(t, exifSegment(tc.tiff))
+
+			cleaned, _, err := stripJPEGLocation(data)
+			if err != nil {
+				t.Fatalf("stripJPEGLocation: %v", err)
+			}
+			// Unreadable EXIF data is left out altogether
+			if bytes.Contains(cleaned, exifHeader) {
+				t.Error("unreadable EXIF block was kept")
+			}
+			if bytes.Contains(cleaned, gpsMarker) {
+				t.Error("GPS coordinates survived")
+			}
+		})
+	}
+}
+
+func TestStripJPEGLocationMalformedSegments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a JPEG", []byte("GIF89a")},
+		{"segment longer than the file", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 'E', 'x'}},
+		{"segment length below two", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xD9}},
+		{"missing marker", []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x04, 0, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := stripJPEGLocation(tc.data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// TestStripJPEGLocationTruncated cuts a JPEG with location data at every length, which must
+// never panic or let the coordinates through
+func TestStripJPEGLocationTruncated(t *testing.T) {
+	data := testJPEG(t, exifSegment(buildTIFF(binary.BigEndian, 3)), xmpSegment())
+	for n := 0; n <= len(data); n++ {
+		cleaned, _, err := stripJPEGLocation(data[:n])
+		if err == nil && bytes.Contains(cleaned, gpsMarker) {
+			t.Fatalf("GPS coordinates survived truncation to %d bytes", n)
+		}
+	}
+}
+
+// TestScrubGPSCorrupted overwrites each byte of the TIFF structure in turn, which must never
+// make scrubGPS read or write out of range
+func TestScrubGPSCorrupted(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		valid := buildTIFF(order, 1)
+		for i := range valid {
+			for _, b := range []byte{0x00, 0x7F, 0xFF} {
+				tiff := append([]byte(nil), valid...)
+				tiff[i] = b
+				scrubGPS(tiff)
+			}
+		}
+	}
+}
+
+func FuzzStripJPEGLocation(f *testing.F) {
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xD9})
+	f.Add(append([]byte{0xFF, 0xD8}, exifSegment(buildTIFF(binary.LittleEndian, 6))...))
+	f.Add(append([]byte{0xFF, 0xD8}, exifSegment(buildTIFF(binary.BigEndian, 8))...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		stripJPEGLocation(data)
+	})
+}
+
+// pngChunk builds a PNG chunk with its checksum
+func pngChunk(kind string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], kind)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testPNG encodes a small image and inserts the given chunks right after its header chunk
+func testPNG(t *testing.T, chunks ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// The signature is followed by the 25-byte IHDR chunk
+	headerEnd := len(pngHeader) + 25
+	out := append([]byte(nil), encoded[:headerEnd]...)
+	for _, c := range chunks {
+		out = append(out, c...)
+	}
+	return append(out, encoded[headerEnd:]...)
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	data := testPNG(t,
+		pngChunk("eXIf", buildTIFF(binary.BigEndian, 1)),
+		pngChunk("tEXt", []byte("Location\x0044.4431 N, 26.1043 E")),
+		pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<exif:GPSLatitude/>")),
+		pngChunk("zTXt", []byte("Comment\x00\x00compressed")),
+		pngChunk("gAMA", []byte{0, 0, 0xB1, 0x8F}),
+	)
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("fixture does not decode: %v", err)
+	}
+
+	cleaned, err := stripPNGMetadata(data)
+	if err != nil {
+		t.Fatalf("stripPNGMetadata: %v", err)
+	}
+	for _, gone := range []string{"eXIf", "tEXt", "iTXt", "zTXt", "GPSLatitude", "26.1043"} {
+		if bytes.Contains(cleaned, []byte(gone)) {
+			t.Errorf("%s survived", gone)
+		}
+	}
+	if bytes.Contains(cleaned, gpsMarker) {
+		t.Error("GPS coordinates survived")
+	}
+	if !bytes.Contains(cleaned, []byte("gAMA")) {
+		t.Error("an unrelated chunk was dropped")
+	}
+	if _, err := png.Decode(bytes.NewReader(cleaned)); err != nil {
+		t.Errorf("cleaned PNG does not decode: %v", err)
+	}
+}
+
+func TestStripPNGMetadataMalformed(t *testing.T) {
+	huge := append(append([]byte(nil), pngHeader...), 0xFF, 0xFF, 0xFF, 0xFF, 't', 'E', 'X', 't', 0, 0, 0, 0)
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a PNG", []byte("\xFF\xD8\xFF\xE0")},
+		{"chunk longer than the file", huge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := stripPNGMetadata(tc.data); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	// Cutting the file anywhere must never panic or keep the text chunk
+	data := testPNG(t, pngChunk("tEXt", []byte("Location\x0044.4431 N")))
+	for n := 0; n <= len(data); n++ {
+		cleaned, err := stripPNGMetadata(data[:n])
+		if err == nil && bytes.Contains(cleaned, []byte("44.4431")) {
+			t.Fatalf("text chunk survived truncation to %d bytes", n)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	jpegData := testJPEG(t, exifSegment(buildTIFF(binary.LittleEndian, 8)))
+	cleaned, contentType, orientation, err := Sanitize(jpegData)
+	if err != nil {
+		t.Fatalf("Sanitize JPEG: %v", err)
+	}
+	if contentType != "image/jpeg" || orientation != 8 || bytes.Contains(cleaned, gpsMarker) {
+		t.Errorf("Sanitize JPEG = %s, orientation %d, GPS kept %v", contentType, orientation, bytes.Contains(cleaned, gpsMarker))
+	}
+
+	pngData := testPNG(t, pngChunk("eXIf", buildTIFF(binary.BigEndian, 1)))
+	cleaned, contentType, _, err = Sanitize(pngData)
+	if err != nil {
+		t.Fatalf("Sanitize PNG: %v", err)
+	}
+	if contentType != "image/png" || bytes.Contains(cleaned, gpsMarker) {
+		t.Errorf("Sanitize PNG = %s, GPS kept %v", contentType, bytes.Contains(cleaned, gpsMarker))
+	}
+
+	if _, _, _, err := Sanitize([]byte("GIF89a\x01\x00\x01\x00")); err != ErrUnsupported {
+		t.Errorf("Sanitize GIF error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Package mail sends plain-text email through a Mailer, so the site can notify the couple and
+// answer guests without caring whether messages go out over SMTP or only to the log.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To []string
+	// ReplyTo is a comma-separated list of addresses
+	ReplyTo string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var (
+	// Outgoing sends the site's email
+	Outgoing Mailer
+	// AdminAddresses receive notifications meant for the couple
+	AdminAddresses []string
+	// SiteURL is the public address of the site, used to link back to it from email; it may be empty
+	SiteURL string
+)
+
+// Initialize sets up Outgoing from the SMTP_* settings, falling back to writing messages to the
+// log when SMTP_HOST is not set, and reads ADMIN_EMAILS and SITE_URL
+func Initialize() error {
+	for _, address := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			if _, err := netmail.ParseAddress(address); err != nil {
+				return fmt.Errorf("invalid address %q in ADMIN_EMAILS: %w", address, err)
+			}
+			AdminAddresses = append(AdminAddresses, address)
+		}
+	}
+	SiteURL = strings.TrimSuffix(os.Getenv("SITE_URL"), "/")
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Printf("SMTP_HOST not set, email will only be logged")
+		Outgoing = LogMailer{}
+		return nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("MAIL_FROM")
+	if _, err := netmail.ParseAddress(from); err != nil {
+		return fmt.Errorf("MAIL_FROM must be set to a valid address when SMTP_HOST is set: %w", err)
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	log.Printf("Sending email through %s:%s", host, port)
+	Outgoing = &SMTPMailer{Addr: net.JoinHostPort(host, port), Auth: auth, From: from}
+	return nil
+}
+
+// SMTPMailer sends email through an SMTP server. The standard library upgrades the connection
+// with STARTTLS when the server offers it, which PlainAuth requires for remote servers.
+type SMTPMailer struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+// Send delivers the message to every recipient
+func (m *SMTPMailer) Send(msg Message) error {
+	data, err := compose(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	envelopeFrom, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.Addr, m.Auth, envelopeFrom.Address, msg.To, data)
+}
+
+// LogMailer writes messages to the log instead of sending them, for development
+type LogMailer struct{}
+
+// Send logs the message
+func (LogMailer) Send(msg Message) error {
+	log.Printf("Email to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+	return nil
+}
+
+// compose formats a message with its headers, encoding the subject and body as UTF-8
+func compose(from string, msg Message) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("email has no recipients")
+	}
+	for _, address := range msg.To {
+		if _, err := netmail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %w", address, err)
+		}
+	}
+	// Replies may go to several addresses, such as both of the couple
+	if msg.ReplyTo != "" {
+		if _, err := netmail.ParseAddressList(msg.ReplyTo); err != nil {
+			return nil, fmt.Errorf("invalid reply-to address %q: %w", msg.ReplyTo, err)
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		// Addresses were parsed above, but never let a value start a new header
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from)
+	header("To", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
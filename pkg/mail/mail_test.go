@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	netmail "net/mail"
+	"strings"
+	"testing"
+)
+
+func TestComposeReplyToSeveralAdmins(t *testing.T) {
+	admins := []string{"Ana <ana@example.com>", "mihai@example.com"}
+	data, err := compose("Wedding <wedding@example.com>", Message{
+		To:      []string{"guest@example.com"},
+		ReplyTo: strings.Join(admins, ", "),
+		Subject: "Re: Parcare la biserică?",
+		Body:    "Da, există parcare.\nVă așteptăm!",
+	})
+	if err != nil {
+		t.Fatalf("compose: %v", err)
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("composed message does not parse: %v", err)
+	}
+
+	replyTo, err := msg.Header.AddressList("Reply-To")
+	if err != nil {
+		t.Fatalf("Reply-To does not parse: %v", err)
+	}
+	if len(replyTo) != 2 || replyTo[0].Address != "ana@example.com" || replyTo[1].Address != "mihai@example.com" {
+		t.Errorf("Reply-To = %v, want both admin addresses", replyTo)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Re: Parcare la biserică?" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(body, []byte("V=C4=83 a=C8=99tept=C4=83m!")) || !bytes.Contains(body, []byte("parcare.\r\n")) {
+		t.Errorf("body is not quoted-printable with CRLF line endings: %q", body)
+	}
+}
+
+func TestComposeRejectsInvalidAddresses(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		msg  Message
+	}{
+		{"no recipients", Message{Subject: "x"}},
+		{"invalid recipient", Message{To: []string{"not an address"}}},
+		{"two recipients in one", Message{To: []string{"a@example.com, b@example.com"}}},
+		{"invalid reply-to", Message{To: []string{"a@example.com"}, ReplyTo: "a@example.com, nope"}},
+		{"header injection in reply-to", Message{To: []string{"a@example.com"}, ReplyTo: "a@example.com\r\nBcc: b@example.com"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := compose("wedding@example.com", tc.msg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestComposeSubjectCannotAddHeaders(t *testing.T) {
+	data, err := compose("wedding@example.com", Message{
+		To:      []string{"a@example.com"},
+		Subject: "Hello\r\nBcc: b@example.com",
+	})
+	if err != nil {
+		t.Fatalf("compose: %v", err)
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("composed message does not parse: %v", err)
+	}
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("subject added a Bcc header: %q", bcc)
+	}
+}
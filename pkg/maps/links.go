@@ -0,0 +1,32 @@
+// Package maps links places to online maps and renders the static map images the site
+// serves itself, so guests' browsers never load third-party map scripts.
+package maps
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// coordinate formats a latitude or longitude with about ten centimetres of precision
+func coordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 6, 64)
+}
+
+// OpenStreetMapURL links to the place on openstreetmap.org with a marker on it
+func OpenStreetMapURL(lat, lon float64) string {
+	return "https://www.openstreetmap.org/?mlat=" + coordinate(lat) + "&mlon=" + coordinate(lon) +
+		"#map=17/" + coordinate(lat) + "/" + coordinate(lon)
+}
+
+// GoogleMapsURL links to the place on Google Maps, which opens the app on phones that have it
+func GoogleMapsURL(lat, lon float64) string {
+	return "https://www.google.com/maps/search/?api=1&query=" + coordinate(lat) + "," + coordinate(lon)
+}
+
+// AppleMapsURL links to the place on Apple Maps, labelled with its name
+func AppleMapsURL(lat, lon float64, name string) string {
+	query := url.Values{}
+	query.Set("ll", coordinate(lat)+","+coordinate(lon))
+	query.Set("q", name)
+	return "https://maps.apple.com/?" + query.Encode()
+}
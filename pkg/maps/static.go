@@ -0,0 +1,300 @@
+package maps
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openStreetMapTiles is OpenStreetMap's standard tile server, whose usage policy allows light
+// use like ours since every map is rendered once and then cached
+const openStreetMapTiles = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+var (
+	// Tiles provides the background of static maps; nil draws markers on a blank background
+	Tiles TileSource
+	// Attribution credits the map data, to be shown with every static map
+	Attribution string
+)
+
+// Initialize sets up Tiles from MAP_TILE_URL, defaulting to OpenStreetMap. MAP_TILE_URL=off
+// disables tiles, and MAP_ATTRIBUTION credits a different tile provider.
+func Initialize() error {
+	template := os.Getenv("MAP_TILE_URL")
+	if template == "off" {
+		log.Printf("Map tiles disabled, static maps only show markers")
+		return nil
+	}
+	if template == "" {
+		template = openStreetMapTiles
+	}
+	if !strings.Contains(template, "{z}") || !strings.Contains(template, "{x}") || !strings.Contains(template, "{y}") {
+		return fmt.Errorf("MAP_TILE_URL must contain {z}, {x} and {y}")
+	}
+
+	Attribution = os.Getenv("MAP_ATTRIBUTION")
+	if Attribution == "" {
+		Attribution = "© OpenStreetMap contributors"
+	}
+
+	userAgent := "wedding-invite static maps"
+	if site := os.Getenv("SITE_URL"); site != "" {
+		userAgent += " (+" + site + ")"
+	}
+
+	Tiles = HTTPTiles{
+		URLTemplate: template,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		UserAgent:   userAgent,
+	}
+	return nil
+}
+
+// Static maps are drawn on the 256-pixel tiles of the Web Mercator projection used by OpenStreetMap
+const (
+	tileSize = 256
+	maxZoom  = 17
+	// singleZoom shows a lone marker with its surrounding streets
+	singleZoom = 15
+	// markerPadding keeps markers this many pixels away from the map's edges
+	markerPadding = 40
+	markerRadius  = 12
+	// maxLatitude is where the Web Mercator projection is cut off
+	maxLatitude = 85.0511
+)
+
+var (
+	// ErrNoMarkers is returned when asked to render a map without markers
+	ErrNoMarkers = errors.New("map has no markers")
+
+	markerColor = color.RGBA{0xe7, 0x5a, 0x7c, 0xff}
+	// blankColor fills the map where a tile could not be loaded
+	blankColor = color.RGBA{0xe8, 0xee, 0xe4, 0xff}
+)
+
+// Marker is a numbered pin on a static map
+type Marker struct {
+	Lat    float64
+	Lon    float64
+	Number int
+}
+
+// TileSource provides the map tiles a static map is drawn on
+type TileSource interface {
+	Tile(z, x, y int) (image.Image, error)
+}
+
+// HTTPTiles downloads tiles from a URL template with {z}, {x} and {y} placeholders, such as
+// "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+type HTTPTiles struct {
+	URLTemplate string
+	Client      *http.Client
+	// UserAgent identifies the site, as tile servers' usage policies require
+	UserAgent string
+}
+
+// Tile downloads and decodes one tile
+func (t HTTPTiles) Tile(z, x, y int) (image.Image, error) {
+	url := strings.NewReplacer("{z}", strconv.Itoa(z), "{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(t.URLTemplate)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", t.UserAgent)
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tile %d/%d/%d: %s", z, x, y, resp.Status)
+	}
+
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
+
+// worldPixel projects a coordinate to pixels of the whole world map at a zoom level
+func worldPixel(lat, lon float64, zoom int) (float64, float64) {
+	lat = math.Max(-maxLatitude, math.Min(maxLatitude, lat))
+	size := float64(tileSize) * math.Exp2(float64(zoom))
+	latRad := lat * math.Pi / 180
+	x := (lon + 180) / 360 * size
+	y := (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * size
+	return x, y
+}
+
+// fitZoom picks the closest zoom level that shows every marker within the padding
+func fitZoom(markers []Marker, width, height int) int {
+	if len(markers) == 1 {
+		return singleZoom
+	}
+	for zoom := maxZoom; zoom > 1; zoom-- {
+		minX, minY, maxX, maxY := bounds(markers, zoom)
+		if maxX-minX <= float64(width-2*markerPadding) && maxY-minY <= float64(height-2*markerPadding) {
+			return zoom
+		}
+	}
+	return 1
+}
+
+// bounds returns the pixel bounding box of the markers at a zoom level
+func bounds(markers []Marker, zoom int) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, m := range markers {
+		x, y := worldPixel(m.Lat, m.Lon, zoom)
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// Render draws the markers on a width by height map, zoomed in as far as still shows them
+// all. When a tile fails to load the rest of the background is left blank, and complete is false;
+// without a tile source the markers are drawn on a blank background.
+func Render(markers []Marker, width, height int, tiles TileSource) (img *image.RGBA, complete bool, err error) {
+	if len(markers) == 0 {
+		return nil, false, ErrNoMarkers
+	}
+
+	zoom := fitZoom(markers, width, height)
+	minX, minY, maxX, maxY := bounds(markers, zoom)
+	left := int(math.Floor((minX+maxX)/2)) - width/2
+	top := int(math.Floor((minY+maxY)/2)) - height/2
+
+	img = image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(blankColor), image.Point{}, draw.Src)
+
+	complete = true
+	worldTiles := 1 << zoom
+rows:
+	for ty := floorDiv(top, tileSize); ty <= floorDiv(top+height-1, tileSize); ty++ {
+		if tiles == nil || ty < 0 || ty >= worldTiles {
+			continue
+		}
+		for tx := floorDiv(left, tileSize); tx <= floorDiv(left+width-1, tileSize); tx++ {
+			tile, err := tiles.Tile(zoom, ((tx%worldTiles)+worldTiles)%worldTiles, ty)
+			if err != nil {
+				// Give up on the rest rather than wait for every tile of an unreachable server
+				complete = false
+				break rows
+			}
+			at := image.Pt(tx*tileSize-left, ty*tileSize-top)
+			draw.Draw(img, image.Rectangle{Min: at, Max: at.Add(image.Pt(tileSize, tileSize))}, tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+
+	// Draw the first markers last, so they stay on top where pins overlap
+	for i := len(markers) - 1; i >= 0; i-- {
+		x, y := worldPixel(markers[i].Lat, markers[i].Lon, zoom)
+		drawMarker(img, x-float64(left), y-float64(top), markers[i].Number)
+	}
+
+	return img, complete, nil
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// drawMarker draws a numbered pin: a white-ringed disc with the number in white
+func drawMarker(img *image.RGBA, x, y float64, number int) {
+	fillCircle(img, x, y, markerRadius+2.5, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	fillCircle(img, x, y, markerRadius, markerColor)
+	drawNumber(img, x, y, number, color.RGBA{0xff, 0xff, 0xff, 0xff})
+}
+
+// fillCircle blends a disc into the image, smoothing its edge over one pixel
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	bounds := img.Bounds()
+	for y := int(cy - r - 1); y <= int(cy+r+1); y++ {
+		for x := int(cx - r - 1); x <= int(cx+r+1); x++ {
+			if !image.Pt(x, y).In(bounds) {
+				continue
+			}
+			coverage := r + 0.5 - math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			if coverage <= 0 {
+				continue
+			}
+			blend(img, x, y, c, math.Min(coverage, 1))
+		}
+	}
+}
+
+// blend mixes an opaque colour into a pixel by the given amount
+func blend(img *image.RGBA, x, y int, c color.RGBA, amount float64) {
+	under := img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-amount) + float64(b)*amount + 0.5)
+	}
+	img.SetRGBA(x, y, color.RGBA{mix(under.R, c.R), mix(under.G, c.G), mix(under.B, c.B), 0xff})
+}
+
+// digits are 3 by 5 pixel glyphs, one row per string, enough to number markers without a font
+var digits = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", ".##", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", ".#.", ".#.", ".#."},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// drawNumber writes a number of one or two digits centred on a point, at twice the glyph size.
+// Larger numbers do not fit in a marker and are left out.
+func drawNumber(img *image.RGBA, cx, cy float64, number int, c color.RGBA) {
+	if number < 0 || number > 99 {
+		return
+	}
+	const scale, glyphWidth, gap = 2, 3, 1
+
+	text := strconv.Itoa(number)
+	width := len(text)*glyphWidth*scale + (len(text)-1)*gap*scale
+	left := int(math.Round(cx)) - width/2
+	top := int(math.Round(cy)) - 5*scale/2
+
+	for i, ch := range text {
+		glyph := digits[ch-'0']
+		x0 := left + i*(glyphWidth+gap)*scale
+		for row, line := range glyph {
+			for col, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						x, y := x0+col*scale+dx, top+row*scale+dy
+						if image.Pt(x, y).In(img.Bounds()) {
+							img.SetRGBA(x, y, c)
+						}
+					}
+				}
+			}
+		}
+	}
+}
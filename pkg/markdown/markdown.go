@@ -0,0 +1,125 @@
+// Package markdown renders the small subset of Markdown admins write in site content:
+// paragraphs, line breaks, bulleted and numbered lists, **bold**, *italic*, `code` and
+// [links](https://example.com). Raw HTML is always escaped and links are limited to web,
+// mail and site-relative addresses, so the output is safe to embed in a page.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	bulletItem   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numberedItem = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	codeSpan     = regexp.MustCompile("`([^`]+)`")
+	link         = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s\x00]+)\)`)
+	bold         = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	italic       = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	placeholder  = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// ToHTML renders Markdown source as HTML
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\x00", "")
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+
+	var out strings.Builder
+	var paragraph []string
+	list := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		flushParagraph()
+		if list != tag {
+			closeList()
+			out.WriteString("<" + tag + ">")
+			list = tag
+		}
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+		if m := bulletItem.FindStringSubmatch(line); m != nil {
+			openList("ul")
+			out.WriteString("<li>" + inline(m[1]) + "</li>")
+			continue
+		}
+		if m := numberedItem.FindStringSubmatch(line); m != nil {
+			openList("ol")
+			out.WriteString("<li>" + inline(m[1]) + "</li>")
+			continue
+		}
+		closeList()
+		paragraph = append(paragraph, inline(strings.TrimSpace(line)))
+	}
+	flushParagraph()
+	closeList()
+
+	return out.String()
+}
+
+// inline escapes a line of text and renders its inline formatting. Code spans and links are
+// set aside while bold and italic are applied, so their contents are left alone.
+func inline(text string) string {
+	text = html.EscapeString(text)
+
+	var stash []string
+	set := func(rendered string) string {
+		stash = append(stash, rendered)
+		return "\x00" + strconv.Itoa(len(stash)-1) + "\x00"
+	}
+
+	text = codeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		return set("<code>" + codeSpan.FindStringSubmatch(m)[1] + "</code>")
+	})
+	// Addresses cannot hold a set-aside code span, which would end up inside the attribute
+	text = link.ReplaceAllStringFunc(text, func(m string) string {
+		parts := link.FindStringSubmatch(m)
+		label, href := emphasis(parts[1]), parts[2]
+		switch {
+		case strings.HasPrefix(href, "https://"), strings.HasPrefix(href, "http://"):
+			return set(`<a href="` + href + `" target="_blank" rel="noopener noreferrer">` + label + "</a>")
+		case strings.HasPrefix(href, "mailto:"), strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//"):
+			return set(`<a href="` + href + `">` + label + "</a>")
+		default:
+			// Anything else, such as javascript: addresses, keeps only its text
+			return set(label)
+		}
+	})
+	text = emphasis(text)
+
+	// A link label can hold a code span set aside before it, so restore until none are left.
+	// Entries only refer to earlier ones, which bounds the passes.
+	for pass := 0; pass <= len(stash) && placeholder.MatchString(text); pass++ {
+		text = placeholder.ReplaceAllStringFunc(text, func(m string) string {
+			i, _ := strconv.Atoi(placeholder.FindStringSubmatch(m)[1])
+			return stash[i]
+		})
+	}
+
+	return text
+}
+
+// emphasis renders **bold** and *italic* text
+func emphasis(text string) string {
+	text = bold.ReplaceAllString(text, "<strong>$1</strong>")
+	return italic.ReplaceAllString(text, "<em>$1</em>")
+}
@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		// Escaping
+		{"script tag", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"ampersand and angle brackets", "a & b < c", "<p>a &amp; b &lt; c</p>"},
+		{"HTML in a code span", "`<b>`", "<p><code>&lt;b&gt;</code></p>"},
+		{"HTML in a link label", "[<img src=x onerror=alert(1)>](/faq)", `<p><a href="/faq">&lt;img src=x onerror=alert(1)&gt;</a></p>`},
+		{"quotes in an address", `[a "b"](https://x.y/?q="><script>)`,
+			`<p><a href="https://x.y/?q=&#34;&gt;&lt;script&gt;" target="_blank" rel="noopener noreferrer">a &#34;b&#34;</a></p>`},
+		{"NUL bytes", "a\x00\x000\x00b", "<p>a0b</p>"},
+
+		// Links
+		{"web link", "[site](https://x.y)", `<p><a href="https://x.y" target="_blank" rel="noopener noreferrer">site</a></p>`},
+		{"mail link", "[mail](mailto:a@b.c)", `<p><a href="mailto:a@b.c">mail</a></p>`},
+		{"site-relative link", "[FAQ](/faq)", `<p><a href="/faq">FAQ</a></p>`},
+		{"javascript address", "[x](javascript:alert(1))", "<p>x)</p>"},
+		{"mixed-case javascript address", "[x](JavaScript:alert(1))", "<p>x)</p>"},
+		{"javascript address without parentheses", "[x](javascript:void)", "<p>x</p>"},
+		{"data address", "[x](data:text/html,hi)", "<p>x</p>"},
+		{"protocol-relative address", "[x](//evil.example)", "<p>x</p>"},
+		{"relative address", "[x](evil.html)", "<p>x</p>"},
+
+		// Nesting
+		{"code span in a link label", "[`docs`](https://x.y)",
+			`<p><a href="https://x.y" target="_blank" rel="noopener noreferrer"><code>docs</code></a></p>`},
+		{"emphasis and code in a link label", "[**bold** `c`](/faq)", `<p><a href="/faq"><strong>bold</strong> <code>c</code></a></p>`},
+		{"code span in an address", "[x](https://a`b`)", "<p>[x](https://a<code>b</code>)</p>"},
+		{"emphasis markers in a code span", "**`a**b`**", "<p><strong><code>a**b</code></strong></p>"},
+		{"code span in a list item", "- `x` and [y](/y)", `<ul><li><code>x</code> and <a href="/y">y</a></li></ul>`},
+
+		// Blocks
+		{"emphasis", "*it* and **bo**", "<p><em>it</em> and <strong>bo</strong></p>"},
+		{"paragraphs and lists", "line one\nline two\n\n- a\n- b\n1. c",
+			"<p>line one<br>line two</p><ul><li>a</li><li>b</li></ul><ol><li>c</li></ol>"},
+		{"Windows line endings", "a\r\nb", "<p>a<br>b</p>"},
+		{"empty", "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ToHTML(tc.src)
+			if got != tc.want {
+				t.Errorf("ToHTML(%q)\n got %q\nwant %q", tc.src, got, tc.want)
+			}
+			if strings.Contains(got, "\x00") {
+				t.Errorf("ToHTML(%q) left a placeholder in %q", tc.src, got)
+			}
+		})
+	}
+}
+
+func TestToHTMLManyCodeSpansInLinks(t *testing.T) {
+	var src strings.Builder
+	for i := 0; i < 20; i++ {
+		src.WriteString("[`a` `b`](/x) ")
+	}
+	if got := ToHTML(src.String()); strings.Contains(got, "\x00") {
+		t.Errorf("placeholder left in %q", got)
+	}
+}
@@ -32,6 +32,21 @@ func RequireAuth(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAdmin lets only the couple's invitations through, as listed in ADMIN_EMAILS. Every
+// admin page needs it, since they show and change other guests' details.
+func RequireAdmin(next http.Handler) http.Handler {
+	return RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := GetSessionFromContext(r)
+		if session == nil || !auth.IsAdmin(session.InvitationEmail) {
+			log.Printf("Admin page %s refused to non-admin session", r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
 // GetSessionFromContext retrieves the session from the request context
 func GetSessionFromContext(r *http.Request) *auth.Session {
 	session, _ := r.Context().Value(SessionKey).(*auth.Session)
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"wedding-invite/pkg/db"
+)
+
+// AccessibilityNeeds lists the kinds of assistance a guest can ask for.
+// Each key has a translation under "accessibility." in the locale files.
+var AccessibilityNeeds = []string{
+	"wheelchair",
+	"step_free",
+	"hearing",
+	"transport",
+}
+
+// AccessibilityReport lists the attending guests who need assistance, for the venue coordinator
+type AccessibilityReport struct {
+	// Needs counts attending guests per accessibility need
+	Needs  map[string]int
+	Guests []Guest
+}
+
+// IsAccessibilityNeed reports whether the key is one of the accessibility needs
+func IsAccessibilityNeed(key string) bool {
+	for _, need := range AccessibilityNeeds {
+		if need == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGuestAccessibility replaces the accessibility needs and notes recorded for a guest of the given invitation
+func SetGuestAccessibility(guestID int64, email string, needs []string, notes string) error {
+	for _, need := range needs {
+		if !IsAccessibilityNeed(need) {
+			return fmt.Errorf("unknown accessibility need %q", need)
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only allow updates for guests that belong to the given invitation
+	notes = strings.TrimSpace(notes)
+	result, err := tx.Exec(`
+		UPDATE guests
+		SET accessibility_notes = ?
+		WHERE id = ? AND invitation_email = ?
+	`, sql.NullString{String: notes, Valid: notes != ""}, guestID, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("guest not found or not authorized")
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM guest_accessibility
+		WHERE guest_id = ?
+	`, guestID); err != nil {
+		return err
+	}
+
+	for _, need := range needs {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO guest_accessibility (guest_id, need)
+			VALUES (?, ?)
+		`, guestID, need); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// attachAccessibility fills in the accessibility needs of the given guests
+func attachAccessibility(guests []Guest) error {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(guests))
+	args := make([]interface{}, len(guests))
+	index := make(map[int64]int, len(guests))
+	for i, g := range guests {
+		placeholders[i] = "?"
+		args[i] = g.ID
+		index[g.ID] = i
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT guest_id, need FROM guest_accessibility
+		WHERE guest_id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]map[string]bool)
+	for rows.Next() {
+		var guestID int64
+		var need string
+		if err := rows.Scan(&guestID, &need); err != nil {
+			return err
+		}
+		if found[guestID] == nil {
+			found[guestID] = make(map[string]bool)
+		}
+		found[guestID][need] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Keep the list order so needs read the same everywhere
+	for guestID, keys := range found {
+		i := index[guestID]
+		guests[i].AccessibilityNeeds = nil
+		for _, need := range AccessibilityNeeds {
+			if keys[need] {
+				guests[i].AccessibilityNeeds = append(guests[i].AccessibilityNeeds, need)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HasAccessibilityNeed reports whether the guest has the given accessibility need recorded
+func (g Guest) HasAccessibilityNeed(key string) bool {
+	for _, need := range g.AccessibilityNeeds {
+		if need == key {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsAssistance reports whether the guest asked for any kind of assistance
+func (g Guest) NeedsAssistance() bool {
+	return len(g.AccessibilityNeeds) > 0 || (g.AccessibilityNotes.Valid && g.AccessibilityNotes.String != "")
+}
+
+// GetAccessibilityReport lists the attending guests who need assistance, grouped by invitation
+func GetAccessibilityReport() (AccessibilityReport, error) {
+	report := AccessibilityReport{Needs: make(map[string]int, len(AccessibilityNeeds))}
+
+	guests, err := GetAllGuests()
+	if err != nil {
+		return report, err
+	}
+
+	for _, g := range guests {
+		// Waitlisted guests are only planned for once they have a place
+		if !g.Attending.Valid || !g.Attending.Bool || g.IsWaitlisted() || !g.NeedsAssistance() {
+			continue
+		}
+
+		for _, need := range g.AccessibilityNeeds {
+			report.Needs[need]++
+		}
+		report.Guests = append(report.Guests, g)
+	}
+
+	return report, nil
+}
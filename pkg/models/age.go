@@ -0,0 +1,137 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"wedding-invite/pkg/db"
+)
+
+// Age categories of a guest. Each key has a translation under "age_categories." in the locale files.
+const (
+	AgeAdult  = "adult"
+	AgeTeen   = "teen"
+	AgeChild  = "child"
+	AgeInfant = "infant"
+)
+
+// AgeCategories lists every age category, oldest first
+var AgeCategories = []string{AgeAdult, AgeTeen, AgeChild, AgeInfant}
+
+// MaxGuestAge is the highest age accepted for a guest
+const MaxGuestAge = 120
+
+const infantsExemptSetting = "infants_exempt_from_limit"
+
+// IsAgeCategory reports whether the key is one of the age categories
+func IsAgeCategory(key string) bool {
+	for _, category := range AgeCategories {
+		if category == key {
+			return true
+		}
+	}
+	return false
+}
+
+// IsChildCategory reports whether guests in the category are offered the children's menu
+func IsChildCategory(category string) bool {
+	return category == AgeChild || category == AgeInfant
+}
+
+// NeedsSeat reports whether the guest needs a seat at a table
+func (g Guest) NeedsSeat() bool {
+	return g.AgeCategory != AgeInfant
+}
+
+// NeedsHighChair reports whether the guest needs a high chair instead of a seat
+func (g Guest) NeedsHighChair() bool {
+	return g.AgeCategory == AgeInfant
+}
+
+// MealAllowedForAge reports whether a meal option may be chosen by a guest in the given age category.
+// Options meant for children are only offered to children and infants.
+func MealAllowedForAge(key, category string) bool {
+	mealLabelsMu.RLock()
+	option, ok := mealLabels[key]
+	mealLabelsMu.RUnlock()
+
+	if !ok || !option.ChildrenOnly {
+		return true
+	}
+	return IsChildCategory(category)
+}
+
+// SetGuestAge records the age category and optional age of a guest of the given invitation
+func SetGuestAge(guestID int64, email, category string, age sql.NullInt64) error {
+	if !IsAgeCategory(category) {
+		return fmt.Errorf("unknown age category %q", category)
+	}
+	if age.Valid && (age.Int64 < 0 || age.Int64 > MaxGuestAge) {
+		return fmt.Errorf("invalid age %d", age.Int64)
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE guests
+		SET age_category = ?, age = ?
+		WHERE id = ? AND invitation_email = ?
+	`, category, age, guestID, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("guest not found or not authorized")
+	}
+
+	return nil
+}
+
+// AreInfantsExemptFromLimit reports whether infants are left out of each invitation's max_guests limit
+func AreInfantsExemptFromLimit() (bool, error) {
+	value, err := getSetting(infantsExemptSetting, "false")
+	if err != nil {
+		return false, err
+	}
+
+	return value == "true", nil
+}
+
+// SetInfantsExemptFromLimit sets whether infants count towards each invitation's max_guests limit
+func SetInfantsExemptFromLimit(exempt bool) error {
+	value := "false"
+	if exempt {
+		value = "true"
+	}
+
+	return setSetting(infantsExemptSetting, value)
+}
+
+// GetSeatCount returns the number of guests of an invitation that count towards its max_guests limit
+func GetSeatCount(email string) (int, error) {
+	exempt, err := AreInfantsExemptFromLimit()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = db.DB.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE invitation_email = ?
+		  AND (? = FALSE OR age_category != ?)
+	`, email, exempt, AgeInfant).Scan(&count)
+
+	return count, err
+}
+
+// CountAgeCategories counts guests per age category
+func CountAgeCategories(guests []Guest) map[string]int {
+	counts := make(map[string]int, len(AgeCategories))
+	for _, g := range guests {
+		counts[g.AgeCategory]++
+	}
+	return counts
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"wedding-invite/pkg/db"
+)
+
+// Allergens lists the 14 allergens that EU food labelling rules require caterers to declare.
+// Each key has a translation under "allergens." in the locale files.
+var Allergens = []string{
+	"gluten",
+	"crustaceans",
+	"eggs",
+	"fish",
+	"peanuts",
+	"soybeans",
+	"milk",
+	"nuts",
+	"celery",
+	"mustard",
+	"sesame",
+	"sulphites",
+	"lupin",
+	"molluscs",
+}
+
+// IsAllergen reports whether the key is part of the allergen taxonomy
+func IsAllergen(key string) bool {
+	for _, allergen := range Allergens {
+		if allergen == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGuestAllergens replaces the allergens recorded for a guest of the given invitation
+func SetGuestAllergens(guestID int64, email string, allergens []string) error {
+	for _, allergen := range allergens {
+		if !IsAllergen(allergen) {
+			return fmt.Errorf("unknown allergen %q", allergen)
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only allow updates for guests that belong to the given invitation
+	var owned int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE id = ? AND invitation_email = ?
+	`, guestID, email).Scan(&owned); err != nil {
+		return err
+	}
+	if owned == 0 {
+		return fmt.Errorf("guest not found or not authorized")
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM guest_allergens
+		WHERE guest_id = ?
+	`, guestID); err != nil {
+		return err
+	}
+
+	for _, allergen := range allergens {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO guest_allergens (guest_id, allergen)
+			VALUES (?, ?)
+		`, guestID, allergen); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// attachAllergens fills in the allergens of the given guests
+func attachAllergens(guests []Guest) error {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(guests))
+	args := make([]interface{}, len(guests))
+	index := make(map[int64]int, len(guests))
+	for i, g := range guests {
+		placeholders[i] = "?"
+		args[i] = g.ID
+		index[g.ID] = i
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT guest_id, allergen FROM guest_allergens
+		WHERE guest_id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]map[string]bool)
+	for rows.Next() {
+		var guestID int64
+		var allergen string
+		if err := rows.Scan(&guestID, &allergen); err != nil {
+			return err
+		}
+		if found[guestID] == nil {
+			found[guestID] = make(map[string]bool)
+		}
+		found[guestID][allergen] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Keep the taxonomy order so lists read the same everywhere
+	for guestID, keys := range found {
+		i := index[guestID]
+		guests[i].Allergens = nil
+		for _, allergen := range Allergens {
+			if keys[allergen] {
+				guests[i].Allergens = append(guests[i].Allergens, allergen)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HasAllergen reports whether the guest has the given allergen recorded
+func (g Guest) HasAllergen(key string) bool {
+	for _, allergen := range g.Allergens {
+		if allergen == key {
+			return true
+		}
+	}
+	return false
+}
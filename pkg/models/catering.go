@@ -0,0 +1,60 @@
+package models
+
+// CateringReport summarizes what the caterer needs to prepare for attending guests
+type CateringReport struct {
+	Attending int
+	// AgeCategories counts attending guests per age category
+	AgeCategories map[string]int
+	// HighChairs counts attending infants, who need a high chair instead of a seat
+	HighChairs int
+	Meals      map[string]int
+	Allergens  map[string]int
+	// Courses counts how often each choice was picked, keyed by course and then choice ID
+	Courses map[string]map[int64]int
+	// SpecialGuests are attending guests with allergens or dietary notes
+	SpecialGuests []Guest
+}
+
+// BuildCateringReport counts meals, course choices and allergens among the attending guests
+func BuildCateringReport(guests []Guest) CateringReport {
+	report := CateringReport{
+		Meals:         make(map[string]int),
+		Allergens:     make(map[string]int),
+		Courses:       make(map[string]map[int64]int),
+		AgeCategories: make(map[string]int),
+	}
+	for _, course := range Courses {
+		report.Courses[course] = make(map[int64]int)
+	}
+
+	for _, g := range guests {
+		// Waitlisted guests are only catered for once they have a place
+		if !g.Attending.Valid || !g.Attending.Bool || g.IsWaitlisted() {
+			continue
+		}
+
+		report.Attending++
+		report.AgeCategories[g.AgeCategory]++
+		if g.NeedsHighChair() {
+			report.HighChairs++
+		}
+		if g.MealPreference.Valid && g.MealPreference.String != "" {
+			report.Meals[g.MealPreference.String]++
+		}
+		for _, allergen := range g.Allergens {
+			report.Allergens[allergen]++
+		}
+		for course, choiceID := range g.Courses {
+			if counts, ok := report.Courses[course]; ok {
+				counts[choiceID]++
+			}
+		}
+
+		hasNotes := g.DietaryRestrictions.Valid && g.DietaryRestrictions.String != ""
+		if len(g.Allergens) > 0 || hasNotes {
+			report.SpecialGuests = append(report.SpecialGuests, g)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,443 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"wedding-invite/pkg/db"
+)
+
+// Courses served at the reception, in the order they are served.
+// Each key has a translation under "courses." in the locale files.
+const (
+	CourseStarter = "starter"
+	CourseMain    = "main"
+	CourseDessert = "dessert"
+)
+
+// Courses lists every course guests choose a dish for
+var Courses = []string{CourseStarter, CourseMain, CourseDessert}
+
+// IsCourse reports whether the key is one of the served courses
+func IsCourse(key string) bool {
+	for _, course := range Courses {
+		if course == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CourseChoice is a dish guests can pick for a course. A choice with meal types
+// is only offered to guests whose meal preference is one of them.
+type CourseChoice struct {
+	ID        int64
+	Course    string
+	LabelEN   string
+	LabelRO   string
+	SortOrder int
+	Active    bool
+	MealTypes []string
+}
+
+// Label returns the choice's label in the given language
+func (c CourseChoice) Label(lang string) string {
+	if lang == "ro" {
+		return c.LabelRO
+	}
+	return c.LabelEN
+}
+
+// AllowsMealType reports whether the choice may be served to a guest with the given meal preference
+func (c CourseChoice) AllowsMealType(mealType string) bool {
+	if len(c.MealTypes) == 0 {
+		return true
+	}
+	for _, allowed := range c.MealTypes {
+		if allowed == mealType {
+			return true
+		}
+	}
+	return false
+}
+
+// courseChoiceLabels caches every choice by ID so labels can be looked up while rendering
+var (
+	courseChoiceLabelsMu sync.RWMutex
+	courseChoiceLabels   = map[int64]CourseChoice{}
+)
+
+// InitializeCourseChoices loads the course choice label cache
+func InitializeCourseChoices() error {
+	return reloadCourseChoiceLabels()
+}
+
+// reloadCourseChoiceLabels refreshes the label cache from the database
+func reloadCourseChoiceLabels() error {
+	choices, err := GetAllCourseChoices()
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[int64]CourseChoice, len(choices))
+	for _, choice := range choices {
+		labels[choice.ID] = choice
+	}
+
+	courseChoiceLabelsMu.Lock()
+	courseChoiceLabels = labels
+	courseChoiceLabelsMu.Unlock()
+
+	return nil
+}
+
+// CourseChoiceLabel returns the label of a course choice in the given language,
+// or an empty string when no such choice exists
+func CourseChoiceLabel(lang string, id int64) string {
+	courseChoiceLabelsMu.RLock()
+	choice, ok := courseChoiceLabels[id]
+	courseChoiceLabelsMu.RUnlock()
+
+	if !ok {
+		return ""
+	}
+	return choice.Label(lang)
+}
+
+// queryCourseChoices retrieves course choices matching the given condition, in menu order
+func queryCourseChoices(where string) ([]CourseChoice, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, course, label_en, label_ro, sort_order, active
+		FROM course_choices
+		` + where + `
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var choices []CourseChoice
+	index := make(map[int64]int)
+
+	for rows.Next() {
+		var c CourseChoice
+		if err := rows.Scan(
+			&c.ID,
+			&c.Course,
+			&c.LabelEN,
+			&c.LabelRO,
+			&c.SortOrder,
+			&c.Active,
+		); err != nil {
+			return nil, err
+		}
+
+		index[c.ID] = len(choices)
+		choices = append(choices, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(choices) == 0 {
+		return choices, nil
+	}
+
+	typeRows, err := db.DB.Query(`
+		SELECT choice_id, meal_type FROM course_choice_meal_types
+		ORDER BY choice_id, meal_type
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer typeRows.Close()
+
+	for typeRows.Next() {
+		var choiceID int64
+		var mealType string
+		if err := typeRows.Scan(&choiceID, &mealType); err != nil {
+			return nil, err
+		}
+		if i, ok := index[choiceID]; ok {
+			choices[i].MealTypes = append(choices[i].MealTypes, mealType)
+		}
+	}
+
+	if err := typeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return choices, nil
+}
+
+// GetActiveCourseChoices retrieves the choices guests can currently pick from
+func GetActiveCourseChoices() ([]CourseChoice, error) {
+	return queryCourseChoices("WHERE active")
+}
+
+// GetAllCourseChoices retrieves every choice, including retired ones
+func GetAllCourseChoices() ([]CourseChoice, error) {
+	return queryCourseChoices("")
+}
+
+// ChoicesForCourse returns the choices that belong to the given course
+func ChoicesForCourse(choices []CourseChoice, course string) []CourseChoice {
+	var filtered []CourseChoice
+	for _, choice := range choices {
+		if choice.Course == course {
+			filtered = append(filtered, choice)
+		}
+	}
+	return filtered
+}
+
+// validateCourseChoice checks the fields an admin entered for a course choice
+func validateCourseChoice(labelEN, labelRO string, mealTypes []string) error {
+	if labelEN == "" || labelRO == "" {
+		return fmt.Errorf("labels are required in every language")
+	}
+
+	known := make(map[string]bool)
+	options, err := GetAllMealOptions()
+	if err != nil {
+		return err
+	}
+	for _, option := range options {
+		known[option.Key] = true
+	}
+	for _, mealType := range mealTypes {
+		if !known[mealType] {
+			return fmt.Errorf("unknown meal type %q", mealType)
+		}
+	}
+
+	return nil
+}
+
+// setCourseChoiceMealTypes replaces the meal types a choice is restricted to
+func setCourseChoiceMealTypes(tx *sql.Tx, id int64, mealTypes []string) error {
+	if _, err := tx.Exec(`
+		DELETE FROM course_choice_meal_types
+		WHERE choice_id = ?
+	`, id); err != nil {
+		return err
+	}
+
+	for _, mealType := range mealTypes {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO course_choice_meal_types (choice_id, meal_type)
+			VALUES (?, ?)
+		`, id, mealType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateCourseChoice adds a new dish to a course, optionally restricted to some meal types
+func CreateCourseChoice(course, labelEN, labelRO string, sortOrder int, mealTypes []string) error {
+	if !IsCourse(course) {
+		return fmt.Errorf("unknown course %q", course)
+	}
+
+	labelEN = strings.TrimSpace(labelEN)
+	labelRO = strings.TrimSpace(labelRO)
+	if err := validateCourseChoice(labelEN, labelRO, mealTypes); err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO course_choices (course, label_en, label_ro, sort_order, active)
+		VALUES (?, ?, ?, ?, TRUE)
+	`, course, labelEN, labelRO, sortOrder)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := setCourseChoiceMealTypes(tx, id, mealTypes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return reloadCourseChoiceLabels()
+}
+
+// UpdateCourseChoice renames, reorders, restricts, retires or reactivates a course choice.
+// The course a choice belongs to is never changed.
+func UpdateCourseChoice(id int64, labelEN, labelRO string, sortOrder int, active bool, mealTypes []string) error {
+	labelEN = strings.TrimSpace(labelEN)
+	labelRO = strings.TrimSpace(labelRO)
+	if err := validateCourseChoice(labelEN, labelRO, mealTypes); err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE course_choices
+		SET label_en = ?,
+		    label_ro = ?,
+		    sort_order = ?,
+		    active = ?
+		WHERE id = ?
+	`, labelEN, labelRO, sortOrder, active, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("course choice not found")
+	}
+
+	if err := setCourseChoiceMealTypes(tx, id, mealTypes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return reloadCourseChoiceLabels()
+}
+
+// SetGuestCourses replaces the course choices of a guest of the given invitation.
+// Each choice must belong to its course and allow the guest's meal preference; retired
+// choices are only accepted when the guest had already picked them. Other choices are
+// left out, and their courses returned so the guest can choose again.
+func SetGuestCourses(guestID int64, email, mealType string, choices map[string]int64) ([]string, error) {
+	all, err := GetAllCourseChoices()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]CourseChoice, len(all))
+	for _, choice := range all {
+		byID[choice.ID] = choice
+	}
+
+	current := []Guest{{ID: guestID}}
+	if err := attachCourses(current); err != nil {
+		return nil, err
+	}
+
+	valid := make(map[string]int64, len(choices))
+	var dropped []string
+	for _, course := range Courses {
+		choiceID := choices[course]
+		if choiceID == 0 {
+			continue
+		}
+
+		choice, ok := byID[choiceID]
+		if !ok || choice.Course != course ||
+			(!choice.Active && current[0].Courses[course] != choiceID) ||
+			!choice.AllowsMealType(mealType) {
+			dropped = append(dropped, course)
+			continue
+		}
+		valid[course] = choiceID
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Only allow updates for guests that belong to the given invitation
+	var owned int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE id = ? AND invitation_email = ?
+	`, guestID, email).Scan(&owned); err != nil {
+		return nil, err
+	}
+	if owned == 0 {
+		return nil, fmt.Errorf("guest not found or not authorized")
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM guest_courses
+		WHERE guest_id = ?
+	`, guestID); err != nil {
+		return nil, err
+	}
+
+	for course, choiceID := range valid {
+		if _, err := tx.Exec(`
+			INSERT INTO guest_courses (guest_id, course, choice_id)
+			VALUES (?, ?, ?)
+		`, guestID, course, choiceID); err != nil {
+			return nil, err
+		}
+	}
+
+	return dropped, tx.Commit()
+}
+
+// attachCourses fills in the course choices of the given guests
+func attachCourses(guests []Guest) error {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(guests))
+	args := make([]interface{}, len(guests))
+	index := make(map[int64]int, len(guests))
+	for i, g := range guests {
+		placeholders[i] = "?"
+		args[i] = g.ID
+		index[g.ID] = i
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT guest_id, course, choice_id FROM guest_courses
+		WHERE guest_id IN (`+strings.Join(placeholders, ", ")+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guestID, choiceID int64
+		var course string
+		if err := rows.Scan(&guestID, &course, &choiceID); err != nil {
+			return err
+		}
+
+		i := index[guestID]
+		if guests[i].Courses == nil {
+			guests[i].Courses = make(map[string]int64)
+		}
+		guests[i].Courses[course] = choiceID
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+	"wedding-invite/pkg/db"
+
+	// Embed the timezone database, the production image has no zoneinfo files
+	_ "time/tzdata"
+)
+
+const (
+	// defaultRSVPDeadline is used when RSVP_DEADLINE is not set
+	defaultRSVPDeadline = "2025-08-15 23:59"
+
+	// defaultRSVPTimezone is used when RSVP_TIMEZONE is not set
+	defaultRSVPTimezone = "Europe/Bucharest"
+
+	// DeadlineInputFormat is the layout of deadlines in configuration and admin forms
+	DeadlineInputFormat = "2006-01-02 15:04"
+
+	// rsvpReopenedSetting is the settings key that reopens RSVPs for everyone
+	rsvpReopenedSetting = "rsvp_reopened"
+)
+
+var (
+	rsvpDeadline time.Time
+	rsvpLocation *time.Location
+)
+
+// InvitationExtension is an invitation together with its personal RSVP deadline, if any
+type InvitationExtension struct {
+	Email         string
+	ExtendedUntil sql.NullTime
+}
+
+// InitializeDeadline loads the RSVP deadline and its timezone from the environment
+func InitializeDeadline() error {
+	timezone := os.Getenv("RSVP_TIMEZONE")
+	if timezone == "" {
+		timezone = defaultRSVPTimezone
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid RSVP_TIMEZONE %q: %w", timezone, err)
+	}
+
+	deadline := os.Getenv("RSVP_DEADLINE")
+	if deadline == "" {
+		deadline = defaultRSVPDeadline
+	}
+
+	parsed, err := ParseDeadline(deadline, location)
+	if err != nil {
+		return fmt.Errorf("invalid RSVP_DEADLINE %q: %w", deadline, err)
+	}
+
+	rsvpLocation = location
+	rsvpDeadline = parsed
+	return nil
+}
+
+// ParseDeadline parses a deadline given either as RFC 3339 or in DeadlineInputFormat
+// local to the given location
+func ParseDeadline(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(location), nil
+	}
+
+	// HTML datetime-local inputs separate date and time with a "T"
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, location); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation(DeadlineInputFormat, value, location)
+}
+
+// RSVPDeadline returns the global RSVP deadline
+func RSVPDeadline() time.Time {
+	return rsvpDeadline
+}
+
+// RSVPLocation returns the timezone deadlines are configured and displayed in
+func RSVPLocation() *time.Location {
+	return rsvpLocation
+}
+
+// IsRSVPReopened reports whether an admin reopened RSVPs for everyone
+func IsRSVPReopened() (bool, error) {
+	value, err := getSetting(rsvpReopenedSetting, "false")
+	if err != nil {
+		return false, err
+	}
+
+	return value == "true", nil
+}
+
+// SetRSVPReopened reopens or closes RSVPs for everyone after the deadline
+func SetRSVPReopened(reopened bool) error {
+	value := "false"
+	if reopened {
+		value = "true"
+	}
+
+	return setSetting(rsvpReopenedSetting, value)
+}
+
+// GetRSVPDeadline returns the deadline for an invitation, taking its extension into account
+func GetRSVPDeadline(email string) (time.Time, error) {
+	var extendedUntil sql.NullTime
+	err := db.DB.QueryRow(`
+		SELECT rsvp_extended_until FROM invitations
+		WHERE email = ?
+	`, email).Scan(&extendedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+
+	if extendedUntil.Valid && extendedUntil.Time.After(rsvpDeadline) {
+		return extendedUntil.Time.In(rsvpLocation), nil
+	}
+
+	return rsvpDeadline, nil
+}
+
+// IsRSVPOpen reports whether an invitation may still submit or change its RSVP
+func IsRSVPOpen(email string) (bool, error) {
+	reopened, err := IsRSVPReopened()
+	if err != nil {
+		return false, err
+	}
+	if reopened {
+		return true, nil
+	}
+
+	deadline, err := GetRSVPDeadline(email)
+	if err != nil {
+		return false, err
+	}
+
+	return time.Now().Before(deadline), nil
+}
+
+// SetRSVPExtension gives an invitation a personal deadline, or removes it when until is zero
+func SetRSVPExtension(email string, until time.Time) error {
+	var extendedUntil sql.NullTime
+	if !until.IsZero() {
+		extendedUntil = sql.NullTime{Time: until, Valid: true}
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE invitations
+		SET rsvp_extended_until = ?
+		WHERE email = ?
+	`, extendedUntil, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
+
+// GetInvitationExtensions retrieves every invitation with its personal deadline
+func GetInvitationExtensions() ([]InvitationExtension, error) {
+	rows, err := db.DB.Query(`
+		SELECT email, rsvp_extended_until
+		FROM invitations
+		ORDER BY email
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []InvitationExtension
+
+	for rows.Next() {
+		var inv InvitationExtension
+		if err := rows.Scan(&inv.Email, &inv.ExtendedUntil); err != nil {
+			return nil, err
+		}
+
+		if inv.ExtendedUntil.Valid {
+			inv.ExtendedUntil.Time = inv.ExtendedUntil.Time.In(rsvpLocation)
+		}
+
+		invitations = append(invitations, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeadline(t *testing.T) {
+	bucharest, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"input format in the location", "2025-08-15 23:59", time.Date(2025, 8, 15, 23, 59, 0, 0, bucharest)},
+		{"datetime-local input", "2025-08-15T23:59", time.Date(2025, 8, 15, 23, 59, 0, 0, bucharest)},
+		{"RFC 3339 keeps its offset", "2025-08-15T20:59:00Z", time.Date(2025, 8, 15, 23, 59, 0, 0, bucharest)},
+		{"winter time", "2025-01-10 12:00", time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDeadline(tc.value, bucharest)
+			if err != nil {
+				t.Fatalf("ParseDeadline(%q): %v", tc.value, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseDeadline(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "15/08/2025", "2025-08-15", "2025-13-01 10:00"} {
+		if _, err := ParseDeadline(value, bucharest); err == nil {
+			t.Errorf("ParseDeadline(%q) accepted an invalid deadline", value)
+		}
+	}
+}
+
+func TestRSVPDeadlineWithExtensions(t *testing.T) {
+	setupTestDB(t)
+
+	now := time.Now()
+	t.Setenv("RSVP_TIMEZONE", "Europe/Bucharest")
+
+	for _, tc := range []struct {
+		name      string
+		deadline  time.Time
+		extension time.Time
+		reopened  bool
+		want      time.Time
+		open      bool
+	}{
+		{"before the deadline", now.Add(time.Hour), time.Time{}, false, now.Add(time.Hour), true},
+		{"after the deadline", now.Add(-time.Hour), time.Time{}, false, now.Add(-time.Hour), false},
+		{"extended past the deadline", now.Add(-time.Hour), now.Add(time.Hour), false, now.Add(time.Hour), true},
+		{"extension ran out", now.Add(-time.Hour), now.Add(-time.Minute), false, now.Add(-time.Minute), false},
+		{"extension earlier than the deadline", now.Add(time.Hour), now.Add(-time.Hour), false, now.Add(time.Hour), true},
+		{"reopened for everyone", now.Add(-time.Hour), time.Time{}, true, now.Add(-time.Hour), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			email := tc.name + "@example.com"
+			createTestInvitation(t, email)
+
+			t.Setenv("RSVP_DEADLINE", tc.deadline.UTC().Format(time.RFC3339Nano))
+			if err := InitializeDeadline(); err != nil {
+				t.Fatal(err)
+			}
+			if err := SetRSVPExtension(email, tc.extension); err != nil {
+				t.Fatal(err)
+			}
+			if err := SetRSVPReopened(tc.reopened); err != nil {
+				t.Fatal(err)
+			}
+
+			deadline, err := GetRSVPDeadline(email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !deadline.Equal(tc.want) {
+				t.Errorf("GetRSVPDeadline = %v, want %v", deadline, tc.want)
+			}
+			if deadline.Location().String() != "Europe/Bucharest" {
+				t.Errorf("deadline is in %v, want Europe/Bucharest", deadline.Location())
+			}
+
+			open, err := IsRSVPOpen(email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if open != tc.open {
+				t.Errorf("IsRSVPOpen = %v, want %v", open, tc.open)
+			}
+		})
+	}
+
+	if err := SetRSVPExtension("missing@example.com", now); err == nil {
+		t.Error("SetRSVPExtension accepted an unknown invitation")
+	}
+}
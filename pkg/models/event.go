@@ -0,0 +1,193 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Event keys double as the locale section that holds each event's texts
+const (
+	EventCeremony  = "ceremony"
+	EventReception = "reception"
+)
+
+// Event represents a part of the wedding day that an invitation can include
+type Event struct {
+	Key    string
+	Start  time.Time
+	End    time.Time
+	MapURL string
+}
+
+// weddingLocation is Bucharest summer time, which is in effect on the wedding day
+var weddingLocation = time.FixedZone("EEST", 3*60*60)
+
+// Events lists every event of the wedding day in chronological order
+var Events = []Event{
+	{
+		Key:    EventCeremony,
+		Start:  time.Date(2025, time.October, 4, 14, 0, 0, 0, weddingLocation),
+		End:    time.Date(2025, time.October, 4, 15, 0, 0, 0, weddingLocation),
+		MapURL: "https://maps.google.com/?q=Biserica+Icoanei+Bucuresti",
+	},
+	{
+		Key:    EventReception,
+		Start:  time.Date(2025, time.October, 4, 18, 0, 0, 0, weddingLocation),
+		End:    time.Date(2025, time.October, 5, 4, 0, 0, 0, weddingLocation),
+		MapURL: "https://maps.google.com/?q=Palatul+Ghica+Tei",
+	},
+}
+
+// InvitationEvents pairs an invitation with the events it has access to
+type InvitationEvents struct {
+	Email  string
+	Events []Event
+}
+
+// GetEvent looks up an event by its key
+func GetEvent(key string) (Event, bool) {
+	for _, event := range Events {
+		if event.Key == key {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+// HasEvent reports whether the given event key is part of the list
+func HasEvent(events []Event, key string) bool {
+	for _, event := range events {
+		if event.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// eventsFromKeys converts stored event keys to events, keeping the order of Events.
+// Invitations without any stored keys have access to every event.
+func eventsFromKeys(keys map[string]bool) []Event {
+	if len(keys) == 0 {
+		return Events
+	}
+
+	var events []Event
+	for _, event := range Events {
+		if keys[event.Key] {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// GetInvitationEvents retrieves the events an invitation may attend
+func GetInvitationEvents(email string) ([]Event, error) {
+	rows, err := db.DB.Query(`
+		SELECT event_key FROM invitation_events
+		WHERE invitation_email = ?
+	`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return eventsFromKeys(keys), nil
+}
+
+// GetAllInvitationEvents retrieves the event access list of every invitation
+func GetAllInvitationEvents() ([]InvitationEvents, error) {
+	rows, err := db.DB.Query(`
+		SELECT i.email, ie.event_key
+		FROM invitations i
+		LEFT JOIN invitation_events ie ON ie.invitation_email = i.email
+		ORDER BY i.email
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []string
+	keysByEmail := make(map[string]map[string]bool)
+
+	for rows.Next() {
+		var email string
+		var key sql.NullString
+		if err := rows.Scan(&email, &key); err != nil {
+			return nil, err
+		}
+
+		if _, ok := keysByEmail[email]; !ok {
+			emails = append(emails, email)
+			keysByEmail[email] = make(map[string]bool)
+		}
+		if key.Valid {
+			keysByEmail[email][key.String] = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	invitations := make([]InvitationEvents, 0, len(emails))
+	for _, email := range emails {
+		invitations = append(invitations, InvitationEvents{
+			Email:  email,
+			Events: eventsFromKeys(keysByEmail[email]),
+		})
+	}
+
+	return invitations, nil
+}
+
+// SetInvitationEvents replaces the events an invitation may attend
+func SetInvitationEvents(email string, keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, key := range keys {
+		if _, ok := GetEvent(key); !ok {
+			return fmt.Errorf("unknown event %q", key)
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM invitation_events
+		WHERE invitation_email = ?
+	`, email); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO invitation_events (invitation_email, event_key)
+			VALUES (?, ?)
+		`, email, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,405 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"wedding-invite/pkg/db"
+)
+
+// FAQCategory groups related questions of the FAQ
+type FAQCategory struct {
+	ID        int64
+	TitleEN   string
+	TitleRO   string
+	SortOrder int
+	Entries   []FAQEntry
+}
+
+// Title returns the category's title in the given language
+func (c FAQCategory) Title(lang string) string {
+	if lang == "ro" {
+		return c.TitleRO
+	}
+	return c.TitleEN
+}
+
+// FAQEntry is a question and its answer. Answers are written in Markdown.
+type FAQEntry struct {
+	ID         int64
+	CategoryID int64
+	QuestionEN string
+	QuestionRO string
+	AnswerEN   string
+	AnswerRO   string
+	SortOrder  int
+	// EventKeys restricts the entry to invitations with one of these events; empty means everyone
+	EventKeys []string
+}
+
+// Question returns the entry's question in the given language
+func (e FAQEntry) Question(lang string) string {
+	if lang == "ro" {
+		return e.QuestionRO
+	}
+	return e.QuestionEN
+}
+
+// Answer returns the entry's Markdown answer in the given language
+func (e FAQEntry) Answer(lang string) string {
+	if lang == "ro" {
+		return e.AnswerRO
+	}
+	return e.AnswerEN
+}
+
+// VisibleTo reports whether an invitation with the given events may see the entry
+func (e FAQEntry) VisibleTo(events []Event) bool {
+	if len(e.EventKeys) == 0 {
+		return true
+	}
+	for _, key := range e.EventKeys {
+		if HasEvent(events, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether the entry's question or answer in the given language contains
+// every word of the search, ignoring case and Romanian diacritics
+func (e FAQEntry) Matches(lang, search string) bool {
+	text := foldSearchText(e.Question(lang) + " " + e.Answer(lang))
+	for _, word := range strings.Fields(foldSearchText(search)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchFolding maps Romanian letters to their plain forms, so "sala" finds "sală"
+var searchFolding = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+)
+
+// foldSearchText lowercases text and strips its diacritics for searching
+func foldSearchText(text string) string {
+	return searchFolding.Replace(strings.ToLower(text))
+}
+
+// FilterFAQ keeps the entries visible to an invitation with the given events that match the
+// search in the given language, dropping categories left without entries
+func FilterFAQ(categories []FAQCategory, events []Event, lang, search string) []FAQCategory {
+	var filtered []FAQCategory
+	for _, category := range categories {
+		var entries []FAQEntry
+		for _, entry := range category.Entries {
+			if entry.VisibleTo(events) && entry.Matches(lang, search) {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) > 0 {
+			category.Entries = entries
+			filtered = append(filtered, category)
+		}
+	}
+	return filtered
+}
+
+// validateFAQEntry checks the texts and event restrictions of an entry
+func validateFAQEntry(questionEN, questionRO, answerEN, answerRO string, eventKeys []string) error {
+	if strings.TrimSpace(questionEN) == "" || strings.TrimSpace(questionRO) == "" {
+		return fmt.Errorf("questions are required in both languages")
+	}
+	if strings.TrimSpace(answerEN) == "" || strings.TrimSpace(answerRO) == "" {
+		return fmt.Errorf("answers are required in both languages")
+	}
+	for _, key := range eventKeys {
+		if _, ok := GetEvent(key); !ok {
+			return fmt.Errorf("unknown event %q", key)
+		}
+	}
+	return nil
+}
+
+// faqCategoryExists checks that an entry's category exists
+func faqCategoryExists(id int64) error {
+	var exists bool
+	if err := db.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM faq_categories WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("category not found")
+	}
+	return nil
+}
+
+// GetFAQ returns every category in order with its entries in order
+func GetFAQ() ([]FAQCategory, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, title_en, title_ro, sort_order
+		FROM faq_categories
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []FAQCategory
+	index := make(map[int64]int)
+	for rows.Next() {
+		var c FAQCategory
+		if err := rows.Scan(&c.ID, &c.TitleEN, &c.TitleRO, &c.SortOrder); err != nil {
+			return nil, err
+		}
+		index[c.ID] = len(categories)
+		categories = append(categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eventKeys, err := getFAQEntryEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	entryRows, err := db.DB.Query(`
+		SELECT id, category_id, question_en, question_ro, answer_en, answer_ro, sort_order
+		FROM faq_entries
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer entryRows.Close()
+
+	for entryRows.Next() {
+		var e FAQEntry
+		if err := entryRows.Scan(&e.ID, &e.CategoryID, &e.QuestionEN, &e.QuestionRO,
+			&e.AnswerEN, &e.AnswerRO, &e.SortOrder); err != nil {
+			return nil, err
+		}
+		e.EventKeys = eventKeys[e.ID]
+		if i, ok := index[e.CategoryID]; ok {
+			categories[i].Entries = append(categories[i].Entries, e)
+		}
+	}
+
+	return categories, entryRows.Err()
+}
+
+// getFAQEntryEvents returns the event restrictions of every restricted entry, in the order of Events
+func getFAQEntryEvents() (map[int64][]string, error) {
+	rows, err := db.DB.Query(`SELECT entry_id, event_key FROM faq_entry_events`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[int64]map[string]bool)
+	for rows.Next() {
+		var id int64
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			return nil, err
+		}
+		if keys[id] == nil {
+			keys[id] = make(map[string]bool)
+		}
+		keys[id][key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eventKeys := make(map[int64][]string, len(keys))
+	for id, set := range keys {
+		for _, event := range Events {
+			if set[event.Key] {
+				eventKeys[id] = append(eventKeys[id], event.Key)
+			}
+		}
+	}
+	return eventKeys, nil
+}
+
+// GetInvitationFAQ returns the categories and entries the invitation may see, matching
+// the search in the given language when there is one
+func GetInvitationFAQ(email, lang, search string) ([]FAQCategory, error) {
+	categories, err := GetFAQ()
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := GetInvitationEvents(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterFAQ(categories, events, lang, search), nil
+}
+
+// CreateFAQCategory adds a category to the FAQ
+func CreateFAQCategory(titleEN, titleRO string, sortOrder int) error {
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return fmt.Errorf("titles are required in both languages")
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO faq_categories (title_en, title_ro, sort_order)
+		VALUES (?, ?, ?)
+	`, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO), sortOrder)
+
+	return err
+}
+
+// UpdateFAQCategory renames or reorders a category
+func UpdateFAQCategory(id int64, titleEN, titleRO string, sortOrder int) error {
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return fmt.Errorf("titles are required in both languages")
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE faq_categories
+		SET title_en = ?, title_ro = ?, sort_order = ?
+		WHERE id = ?
+	`, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO), sortOrder, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("category not found")
+	}
+
+	return nil
+}
+
+// DeleteFAQCategory removes a category once it has no entries left
+func DeleteFAQCategory(id int64) error {
+	var entries int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM faq_entries WHERE category_id = ?`, id).Scan(&entries); err != nil {
+		return err
+	}
+	if entries > 0 {
+		return fmt.Errorf("category still has %d questions", entries)
+	}
+
+	_, err := db.DB.Exec(`DELETE FROM faq_categories WHERE id = ?`, id)
+	return err
+}
+
+// CreateFAQEntry adds a question to a category, restricted to invitations with one of the
+// given events unless eventKeys is empty
+func CreateFAQEntry(categoryID int64, questionEN, questionRO, answerEN, answerRO string, sortOrder int, eventKeys []string) error {
+	if err := validateFAQEntry(questionEN, questionRO, answerEN, answerRO, eventKeys); err != nil {
+		return err
+	}
+	if err := faqCategoryExists(categoryID); err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO faq_entries (category_id, question_en, question_ro, answer_en, answer_ro, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, categoryID, strings.TrimSpace(questionEN), strings.TrimSpace(questionRO),
+		strings.TrimSpace(answerEN), strings.TrimSpace(answerRO), sortOrder)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range eventKeys {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO faq_entry_events (entry_id, event_key)
+			VALUES (?, ?)
+		`, id, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateFAQEntry changes a question, moving it to another category if needed, and replaces
+// its event restrictions
+func UpdateFAQEntry(id, categoryID int64, questionEN, questionRO, answerEN, answerRO string, sortOrder int, eventKeys []string) error {
+	if err := validateFAQEntry(questionEN, questionRO, answerEN, answerRO, eventKeys); err != nil {
+		return err
+	}
+	if err := faqCategoryExists(categoryID); err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE faq_entries
+		SET category_id = ?, question_en = ?, question_ro = ?, answer_en = ?, answer_ro = ?, sort_order = ?
+		WHERE id = ?
+	`, categoryID, strings.TrimSpace(questionEN), strings.TrimSpace(questionRO),
+		strings.TrimSpace(answerEN), strings.TrimSpace(answerRO), sortOrder, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("question not found")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM faq_entry_events WHERE entry_id = ?`, id); err != nil {
+		return err
+	}
+	for _, key := range eventKeys {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO faq_entry_events (entry_id, event_key)
+			VALUES (?, ?)
+		`, id, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFAQEntry removes a question and its event restrictions
+func DeleteFAQEntry(id int64) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM faq_entry_events WHERE entry_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM faq_entries WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,508 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"wedding-invite/pkg/db"
+	"wedding-invite/pkg/imaging"
+	"wedding-invite/pkg/storage"
+)
+
+// GalleryVariantSizes are the longest sides of the resized copies made of every gallery photo,
+// for srcset. Photos smaller than a size are not scaled up to it.
+var GalleryVariantSizes = []int{480, 960, 1600}
+
+// GalleryAlbum is a titled group of gallery photos
+type GalleryAlbum struct {
+	ID        int64
+	Slug      string
+	TitleEN   string
+	TitleRO   string
+	SortOrder int
+	Photos    []GalleryPhoto
+}
+
+// Title returns the album's title in the given language
+func (a GalleryAlbum) Title(lang string) string {
+	if lang == "ro" {
+		return a.TitleRO
+	}
+	return a.TitleEN
+}
+
+// GalleryPhoto is a photo of the couple's gallery. The original is kept in storage under
+// FileKey next to its resized JPEG variants.
+type GalleryPhoto struct {
+	ID        int64
+	AlbumID   int64
+	FileKey   string
+	CaptionEN string
+	CaptionRO string
+	Width     int
+	Height    int
+	// Variants are the pixel widths of the resized copies, smallest first
+	Variants  []int
+	SortOrder int
+	// Featured photos are shown on the wedding page, the first one large
+	Featured bool
+}
+
+// Caption returns the photo's caption in the given language
+func (p GalleryPhoto) Caption(lang string) string {
+	if lang == "ro" {
+		return p.CaptionRO
+	}
+	return p.CaptionEN
+}
+
+// VariantKey returns the storage key of the photo's resized copy of the given width
+func (p GalleryPhoto) VariantKey(width int) string {
+	return variantKey(p.FileKey, width)
+}
+
+// HasVariant reports whether the photo has a resized copy of the given width
+func (p GalleryPhoto) HasVariant(width int) bool {
+	for _, w := range p.Variants {
+		if w == width {
+			return true
+		}
+	}
+	return false
+}
+
+// LargestVariant returns the width of the photo's largest resized copy
+func (p GalleryPhoto) LargestVariant() int {
+	if len(p.Variants) == 0 {
+		return 0
+	}
+	return p.Variants[len(p.Variants)-1]
+}
+
+// variantKey names a resized copy after its original, e.g. "gallery/3f2a-960.jpg"
+func variantKey(fileKey string, width int) string {
+	return strings.TrimSuffix(fileKey, filepath.Ext(fileKey)) + "-" + strconv.Itoa(width) + ".jpg"
+}
+
+// defaultGalleryAlbums seeds the albums the first time the site starts
+var defaultGalleryAlbums = []GalleryAlbum{
+	{Slug: "engagement", TitleEN: "Engagement", TitleRO: "Logodna"},
+	{Slug: "ceremony", TitleEN: "Ceremony", TitleRO: "Ceremonia"},
+	{Slug: "party", TitleEN: "Party", TitleRO: "Petrecerea"},
+}
+
+// defaultGalleryPhotos are the photos the wedding page showed before the gallery existed.
+// They are imported into the engagement album, featured, when the gallery is empty.
+var defaultGalleryPhotos = []string{
+	"static/images/Couples_Ramona_Bogdan_002.jpg",
+	"static/images/optimized/Couple_Sess_Ramona_Bogdan-215.jpg",
+	"static/images/optimized/Couple_Sess_Ramona_Bogdan-206.jpg",
+	"static/images/optimized/Couples_Ramona_Bogdan_001.jpg",
+}
+
+// InitializeGallery seeds the default albums and photos if the gallery is empty
+func InitializeGallery() error {
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM gallery_albums`).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		for i, album := range defaultGalleryAlbums {
+			if err := CreateGalleryAlbum(album.Slug, album.TitleEN, album.TitleRO, (i+1)*10); err != nil {
+				return fmt.Errorf("failed to seed gallery album %s: %w", album.Slug, err)
+			}
+		}
+	}
+
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM gallery_photos`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var albumID int64
+	if err := db.DB.QueryRow(`SELECT id FROM gallery_albums WHERE slug = 'engagement'`).Scan(&albumID); err != nil {
+		// The engagement album was removed; nothing to seed into
+		return nil
+	}
+	for _, path := range defaultGalleryPhotos {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read gallery photo %s: %w", path, err)
+		}
+		id, err := AddGalleryPhoto(albumID, data, "Ramona and Bogdan", "Ramona și Bogdan")
+		if err != nil {
+			return fmt.Errorf("failed to seed gallery photo %s: %w", path, err)
+		}
+		if _, err := db.DB.Exec(`UPDATE gallery_photos SET featured = TRUE WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var albumSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// CreateGalleryAlbum adds an album. Its slug names it in download links and never changes.
+func CreateGalleryAlbum(slug, titleEN, titleRO string, sortOrder int) error {
+	slug = strings.TrimSpace(slug)
+	if !albumSlug.MatchString(slug) {
+		return fmt.Errorf("album slug must be lowercase letters, digits and dashes")
+	}
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return fmt.Errorf("titles are required in both languages")
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO gallery_albums (slug, title_en, title_ro, sort_order)
+		VALUES (?, ?, ?, ?)
+	`, slug, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO), sortOrder)
+
+	return err
+}
+
+// UpdateGalleryAlbum renames or reorders an album
+func UpdateGalleryAlbum(id int64, titleEN, titleRO string, sortOrder int) error {
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return fmt.Errorf("titles are required in both languages")
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE gallery_albums
+		SET title_en = ?, title_ro = ?, sort_order = ?
+		WHERE id = ?
+	`, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO), sortOrder, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("album not found")
+	}
+
+	return nil
+}
+
+// DeleteGalleryAlbum removes an empty album
+func DeleteGalleryAlbum(id int64) error {
+	var photos int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM gallery_photos WHERE album_id = ?`, id).Scan(&photos); err != nil {
+		return err
+	}
+	if photos > 0 {
+		return fmt.Errorf("album still has %d photos", photos)
+	}
+
+	_, err := db.DB.Exec(`DELETE FROM gallery_albums WHERE id = ?`, id)
+	return err
+}
+
+// AddGalleryPhoto stores a JPEG or PNG photo at the end of an album, stripped of its location,
+// and generates its resized variants. It returns the new photo's ID.
+func AddGalleryPhoto(albumID int64, data []byte, captionEN, captionRO string) (int64, error) {
+	var exists bool
+	if err := db.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM gallery_albums WHERE id = ?)`, albumID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, fmt.Errorf("album not found")
+	}
+
+	cleaned, contentType, orientation, err := imaging.Sanitize(data)
+	if err != nil {
+		return 0, err
+	}
+	width, height, err := imaging.Size(cleaned, orientation)
+	if err != nil {
+		return 0, err
+	}
+
+	suffix := ".jpg"
+	if contentType == "image/png" {
+		suffix = ".png"
+	}
+	fileKey, err := newStorageKey("gallery", suffix)
+	if err != nil {
+		return 0, err
+	}
+	if err := storage.Uploads.Save(fileKey, bytes.NewReader(cleaned)); err != nil {
+		return 0, err
+	}
+
+	variants, err := generateGalleryVariants(fileKey, cleaned, orientation)
+	if err != nil {
+		deletePhotoFiles(fileKey)
+		return 0, err
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO gallery_photos (album_id, file_key, caption_en, caption_ro, width, height, variant_widths, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(sort_order), 0) + 10 FROM gallery_photos WHERE album_id = ?))
+	`, albumID, fileKey, strings.TrimSpace(captionEN), strings.TrimSpace(captionRO), width, height,
+		joinWidths(variants), albumID)
+	if err != nil {
+		deleteGalleryFiles(fileKey, variants)
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// generateGalleryVariants stores the resized copies of a photo and returns their widths.
+// Sizes beyond the photo's own are replaced by one copy at its full size.
+func generateGalleryVariants(fileKey string, data []byte, orientation int) ([]int, error) {
+	largest := GalleryVariantSizes[len(GalleryVariantSizes)-1]
+	img, err := imaging.Decode(data, orientation, largest)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	longest := max(bounds.Dx(), bounds.Dy())
+	var widths []int
+	for _, size := range GalleryVariantSizes {
+		variant := imaging.Fit(img, size)
+		encoded, err := imaging.EncodeJPEG(variant, 82)
+		if err != nil {
+			return nil, err
+		}
+
+		width := variant.Bounds().Dx()
+		if len(widths) > 0 && widths[len(widths)-1] == width {
+			continue
+		}
+		if err := storage.Uploads.Save(variantKey(fileKey, width), bytes.NewReader(encoded)); err != nil {
+			return nil, err
+		}
+		widths = append(widths, width)
+		if size >= longest {
+			break
+		}
+	}
+
+	return widths, nil
+}
+
+// RegenerateGalleryVariants remakes the resized copies of every gallery photo from its
+// original, for when GalleryVariantSizes change. It returns how many photos were processed.
+func RegenerateGalleryVariants() (int, error) {
+	photos, err := queryGalleryPhotos("")
+	if err != nil {
+		return 0, err
+	}
+
+	for i, photo := range photos {
+		file, err := storage.Uploads.Open(photo.FileKey)
+		if err != nil {
+			return i, err
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return i, err
+		}
+
+		// Originals were stripped on upload, so this only reads the orientation
+		_, _, orientation, err := imaging.Sanitize(data)
+		if err != nil {
+			return i, err
+		}
+		variants, err := generateGalleryVariants(photo.FileKey, data, orientation)
+		if err != nil {
+			return i, err
+		}
+
+		if _, err := db.DB.Exec(`
+			UPDATE gallery_photos SET variant_widths = ? WHERE id = ?
+		`, joinWidths(variants), photo.ID); err != nil {
+			return i, err
+		}
+
+		kept := GalleryPhoto{Variants: variants}
+		for _, width := range photo.Variants {
+			if !kept.HasVariant(width) {
+				deletePhotoFiles(photo.VariantKey(width))
+			}
+		}
+	}
+
+	return len(photos), nil
+}
+
+// UpdateGalleryPhoto changes a photo's album, captions, position and whether it is featured
+func UpdateGalleryPhoto(id, albumID int64, captionEN, captionRO string, sortOrder int, featured bool) error {
+	result, err := db.DB.Exec(`
+		UPDATE gallery_photos
+		SET album_id = ?, caption_en = ?, caption_ro = ?, sort_order = ?, featured = ?
+		WHERE id = ? AND EXISTS (SELECT 1 FROM gallery_albums WHERE id = ?)
+	`, albumID, strings.TrimSpace(captionEN), strings.TrimSpace(captionRO), sortOrder, featured, id, albumID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("photo or album not found")
+	}
+
+	return nil
+}
+
+// DeleteGalleryPhoto removes a photo with its original and resized copies, taking it off
+// any story entry that showed it
+func DeleteGalleryPhoto(id int64) error {
+	photo, err := GetGalleryPhoto(id)
+	if err != nil {
+		return err
+	}
+	if photo == nil {
+		return fmt.Errorf("photo not found")
+	}
+
+	if _, err := db.DB.Exec(`DELETE FROM gallery_photos WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := db.DB.Exec(`UPDATE story_entries SET photo_id = NULL WHERE photo_id = ?`, id); err != nil {
+		return err
+	}
+
+	deleteGalleryFiles(photo.FileKey, photo.Variants)
+	return nil
+}
+
+// deleteGalleryFiles removes a gallery photo's original and variants from storage
+func deleteGalleryFiles(fileKey string, variants []int) {
+	keys := []string{fileKey}
+	for _, width := range variants {
+		keys = append(keys, variantKey(fileKey, width))
+	}
+	deletePhotoFiles(keys...)
+}
+
+// joinWidths stores variant widths as a comma-separated list
+func joinWidths(widths []int) string {
+	parts := make([]string, len(widths))
+	for i, width := range widths {
+		parts[i] = strconv.Itoa(width)
+	}
+	return strings.Join(parts, ",")
+}
+
+// queryGalleryPhotos returns photos in album and display order, filtered by an optional condition
+func queryGalleryPhotos(where string, args ...interface{}) ([]GalleryPhoto, error) {
+	query := `
+		SELECT p.id, p.album_id, p.file_key, p.caption_en, p.caption_ro, p.width, p.height,
+		       p.variant_widths, p.sort_order, p.featured
+		FROM gallery_photos p
+		JOIN gallery_albums a ON a.id = p.album_id
+	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY a.sort_order, a.id, p.sort_order, p.id"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []GalleryPhoto
+	for rows.Next() {
+		var p GalleryPhoto
+		var widths string
+		if err := rows.Scan(&p.ID, &p.AlbumID, &p.FileKey, &p.CaptionEN, &p.CaptionRO, &p.Width, &p.Height,
+			&widths, &p.SortOrder, &p.Featured); err != nil {
+			return nil, err
+		}
+		for _, part := range strings.Split(widths, ",") {
+			if width, err := strconv.Atoi(part); err == nil {
+				p.Variants = append(p.Variants, width)
+			}
+		}
+		photos = append(photos, p)
+	}
+
+	return photos, rows.Err()
+}
+
+// GetGalleryPhoto returns a photo by ID, or nil if there is none
+func GetGalleryPhoto(id int64) (*GalleryPhoto, error) {
+	photos, err := queryGalleryPhotos("p.id = ?", id)
+	if err != nil || len(photos) == 0 {
+		return nil, err
+	}
+	return &photos[0], nil
+}
+
+// GetFeaturedGalleryPhotos returns the photos shown on the wedding page
+func GetFeaturedGalleryPhotos() ([]GalleryPhoto, error) {
+	return queryGalleryPhotos("p.featured = TRUE")
+}
+
+// GetGalleryAlbums returns every album in order with its photos
+func GetGalleryAlbums() ([]GalleryAlbum, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, slug, title_en, title_ro, sort_order
+		FROM gallery_albums
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []GalleryAlbum
+	index := make(map[int64]int)
+	for rows.Next() {
+		var a GalleryAlbum
+		if err := rows.Scan(&a.ID, &a.Slug, &a.TitleEN, &a.TitleRO, &a.SortOrder); err != nil {
+			return nil, err
+		}
+		index[a.ID] = len(albums)
+		albums = append(albums, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	photos, err := queryGalleryPhotos("")
+	if err != nil {
+		return nil, err
+	}
+	for _, photo := range photos {
+		if i, ok := index[photo.AlbumID]; ok {
+			albums[i].Photos = append(albums[i].Photos, photo)
+		}
+	}
+
+	return albums, nil
+}
+
+// GetGalleryAlbum returns an album with its photos by slug, or nil if there is none
+func GetGalleryAlbum(slug string) (*GalleryAlbum, error) {
+	albums, err := GetGalleryAlbums()
+	if err != nil {
+		return nil, err
+	}
+	for _, album := range albums {
+		if album.Slug == slug {
+			return &album, nil
+		}
+	}
+	return nil, nil
+}
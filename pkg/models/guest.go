@@ -16,25 +16,31 @@ type Guest struct {
 	MealPreference      sql.NullString
 	DietaryRestrictions sql.NullString
 	LastUpdated         time.Time
-}
-
-// MealOptions defines available meal choices
-var MealOptions = []string{
-	"Standard",
-	"Vegetarian",           // Meniu vegetarian nunta
-	"Ovo-Lacto Vegetarian", // Meniu ovo-lacto vegetarian nunta
-	"Ovo-Lacto with Fish",  // Meniu ovo-lacto cu peste nunta
-	"Muslim",               // Meniu musulmani nunta
-	"Gluten-Free",          // Meniu fara Gluten
-	"Lactose-Free",         // Meniu fara lactoza
-	"Child",
+	AgeCategory         string
+	Age                 sql.NullInt64
+	Allergens           []string
+	// Courses maps each course to the ID of the chosen course choice
+	Courses map[string]int64
+	// Named guests were invited by name by an admin and cannot be removed or renamed by the invitee
+	Named bool
+	// VenueStatus tells whether an attending guest holds one of the venue's places or is on its waitlist
+	VenueStatus  string
+	WaitlistedAt sql.NullTime
+	// PromotedAt is when the guest moved from the waitlist to a place at the venue
+	PromotedAt sql.NullTime
+	// AccessibilityNeeds lists the assistance the guest needs at the venue and between venues
+	AccessibilityNeeds []string
+	AccessibilityNotes sql.NullString
+	// Shuttles lists the IDs of the shuttle runs the guest has a seat on
+	Shuttles []int64
 }
 
 // GetGuestsByInvitation retrieves all guests for a specific invitation
 func GetGuestsByInvitation(email string) ([]Guest, error) {
 	rows, err := db.DB.Query(`
 		SELECT id, invitation_email, name, attending, meal_preference, 
-		       dietary_restrictions, last_updated
+		       dietary_restrictions, last_updated, age_category, age, named,
+		       venue_status, waitlisted_at, promoted_at, accessibility_notes
 		FROM guests
 		WHERE invitation_email = ?
 		ORDER BY id
@@ -56,6 +62,13 @@ func GetGuestsByInvitation(email string) ([]Guest, error) {
 			&g.MealPreference,
 			&g.DietaryRestrictions,
 			&g.LastUpdated,
+			&g.AgeCategory,
+			&g.Age,
+			&g.Named,
+			&g.VenueStatus,
+			&g.WaitlistedAt,
+			&g.PromotedAt,
+			&g.AccessibilityNotes,
 		); err != nil {
 			return nil, err
 		}
@@ -67,6 +80,10 @@ func GetGuestsByInvitation(email string) ([]Guest, error) {
 		return nil, err
 	}
 
+	if err := attachGuestDetails(guests); err != nil {
+		return nil, err
+	}
+
 	return guests, nil
 }
 
@@ -74,7 +91,8 @@ func GetGuestsByInvitation(email string) ([]Guest, error) {
 func GetAllGuests() ([]Guest, error) {
 	rows, err := db.DB.Query(`
 		SELECT id, invitation_email, name, attending, meal_preference, 
-		       dietary_restrictions, last_updated
+		       dietary_restrictions, last_updated, age_category, age, named,
+		       venue_status, waitlisted_at, promoted_at, accessibility_notes
 		FROM guests
 		ORDER BY invitation_email, id
 	`)
@@ -95,6 +113,13 @@ func GetAllGuests() ([]Guest, error) {
 			&g.MealPreference,
 			&g.DietaryRestrictions,
 			&g.LastUpdated,
+			&g.AgeCategory,
+			&g.Age,
+			&g.Named,
+			&g.VenueStatus,
+			&g.WaitlistedAt,
+			&g.PromotedAt,
+			&g.AccessibilityNotes,
 		); err != nil {
 			return nil, err
 		}
@@ -106,6 +131,10 @@ func GetAllGuests() ([]Guest, error) {
 		return nil, err
 	}
 
+	if err := attachGuestDetails(guests); err != nil {
+		return nil, err
+	}
+
 	return guests, nil
 }
 
@@ -124,7 +153,7 @@ func CreateGuest(email, name string) (int64, error) {
 		return 0, err
 	}
 
-	return id, nil
+	return id, bumpRSVPVersion(email)
 }
 
 // UpdateGuestRSVP updates a guest's RSVP status
@@ -143,11 +172,11 @@ func UpdateGuestRSVP(id int64, attending bool, mealPreference, dietaryRestrictio
 
 // UpdateGuestName updates a guest's name
 func UpdateGuestName(id int64, email, name string) error {
-	// Only allow updates for guests that belong to the given invitation
+	// Only allow updates for guests that belong to the given invitation and were not named by an admin
 	_, err := db.DB.Exec(`
 		UPDATE guests
 		SET name = ?
-		WHERE id = ? AND invitation_email = ?
+		WHERE id = ? AND invitation_email = ? AND named = FALSE
 	`, name, id, email)
 
 	return err
@@ -155,11 +184,16 @@ func UpdateGuestName(id int64, email, name string) error {
 
 // DeleteGuest removes a guest from the database
 func DeleteGuest(id int64, email string) error {
-	// Only allow deletion if the guest belongs to the given invitation
+	return deleteGuest(id, email, false)
+}
+
+// deleteGuest removes a guest that belongs to the given invitation, along with its details.
+// Guests named by an admin can only be removed by passing named.
+func deleteGuest(id int64, email string, named bool) error {
 	result, err := db.DB.Exec(`
 		DELETE FROM guests
-		WHERE id = ? AND invitation_email = ?
-	`, id, email)
+		WHERE id = ? AND invitation_email = ? AND named = ?
+	`, id, email, named)
 	if err != nil {
 		return err
 	}
@@ -174,7 +208,84 @@ func DeleteGuest(id int64, email string) error {
 		return fmt.Errorf("guest not found or not authorized")
 	}
 
-	return nil
+	if err := bumpRSVPVersion(email); err != nil {
+		return err
+	}
+
+	// Remove the structured details that belonged to the guest
+	_, err = db.DB.Exec(`
+		DELETE FROM guest_allergens
+		WHERE guest_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		DELETE FROM guest_courses
+		WHERE guest_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		DELETE FROM guest_accessibility
+		WHERE guest_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	// Free the guest's shuttle seats
+	_, err = db.DB.Exec(`
+		DELETE FROM shuttle_bookings
+		WHERE guest_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	// Free the guest's place in the seating plan
+	_, err = db.DB.Exec(`
+		DELETE FROM seat_assignments
+		WHERE guest_id = ?
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		DELETE FROM seating_separations
+		WHERE guest_id = ? OR other_guest_id = ?
+	`, id, id)
+
+	return err
+}
+
+// attachGuestDetails fills in the structured details stored alongside the given guests
+func attachGuestDetails(guests []Guest) error {
+	if err := attachAllergens(guests); err != nil {
+		return err
+	}
+	if err := attachAccessibility(guests); err != nil {
+		return err
+	}
+	if err := attachShuttles(guests); err != nil {
+		return err
+	}
+	return attachCourses(guests)
+}
+
+// HasResponded reports whether anyone in the invitation has answered the RSVP
+func HasResponded(email string) (bool, error) {
+	var count int
+	err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE invitation_email = ? AND attending IS NOT NULL
+	`, email).Scan(&count)
+
+	return count > 0, err
 }
 
 // GetGuestCount returns the number of guests for an invitation
@@ -188,15 +299,24 @@ func GetGuestCount(email string) (int, error) {
 	return count, err
 }
 
-// GetMaxGuestCount retrieves the maximum allowed guests for an invitation
+// GetMaxGuestCount retrieves the maximum allowed guests for an invitation. Invitations
+// with named guests allow those guests plus their plus-one slots; others allow max_guests.
 func GetMaxGuestCount(email string) (int, error) {
-	var maxGuests int
+	var maxGuests, plusOnes, named int
 	err := db.DB.QueryRow(`
-		SELECT max_guests FROM invitations
+		SELECT max_guests, plus_ones,
+		       (SELECT COUNT(*) FROM guests WHERE invitation_email = invitations.email AND named)
+		FROM invitations
 		WHERE email = ?
-	`, email).Scan(&maxGuests)
+	`, email).Scan(&maxGuests, &plusOnes, &named)
+	if err != nil {
+		return 0, err
+	}
 
-	return maxGuests, err
+	if named > 0 {
+		return named + plusOnes, nil
+	}
+	return maxGuests, nil
 }
 
 // CheckCanAddGuest verifies if another guest can be added to the invitation
@@ -206,12 +326,57 @@ func CheckCanAddGuest(email string) (bool, error) {
 		return false, err
 	}
 
-	currentCount, err := GetGuestCount(email)
+	// Infants may not take up a seat, depending on the site settings
+	seats, err := GetSeatCount(email)
+	if err != nil {
+		return false, err
+	}
+
+	return seats < maxGuests, nil
+}
+
+// GetRSVPVersion retrieves the version of an invitation's guest set,
+// which increases with every accepted RSVP submission and whenever guests are added or removed
+func GetRSVPVersion(email string) (int64, error) {
+	var version int64
+	err := db.DB.QueryRow(`
+		SELECT rsvp_version FROM invitations
+		WHERE email = ?
+	`, email).Scan(&version)
+
+	return version, err
+}
+
+// ClaimRSVPVersion atomically moves the guest set to the next version if it is
+// still at the given one. It returns false when someone else saved in between.
+func ClaimRSVPVersion(email string, version int64) (bool, error) {
+	result, err := db.DB.Exec(`
+		UPDATE invitations
+		SET rsvp_version = rsvp_version + 1
+		WHERE email = ? AND rsvp_version = ?
+	`, email, version)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return false, err
 	}
 
-	return currentCount < maxGuests, nil
+	return rows == 1, nil
+}
+
+// bumpRSVPVersion moves the guest set to the next version after guests were added or removed
+// outside an RSVP submission, so forms loaded before the change are rejected
+func bumpRSVPVersion(email string) error {
+	_, err := db.DB.Exec(`
+		UPDATE invitations
+		SET rsvp_version = rsvp_version + 1
+		WHERE email = ?
+	`, email)
+
+	return err
 }
 
 // GetGuest retrieves a specific guest by ID
@@ -219,7 +384,8 @@ func GetGuest(id int64) (*Guest, error) {
 	var g Guest
 	err := db.DB.QueryRow(`
 		SELECT id, invitation_email, name, attending, meal_preference, 
-		       dietary_restrictions, last_updated
+		       dietary_restrictions, last_updated, age_category, age, named,
+		       venue_status, waitlisted_at, promoted_at, accessibility_notes
 		FROM guests
 		WHERE id = ?
 	`, id).Scan(
@@ -230,12 +396,24 @@ func GetGuest(id int64) (*Guest, error) {
 		&g.MealPreference,
 		&g.DietaryRestrictions,
 		&g.LastUpdated,
+		&g.AgeCategory,
+		&g.Age,
+		&g.Named,
+		&g.VenueStatus,
+		&g.WaitlistedAt,
+		&g.PromotedAt,
+		&g.AccessibilityNotes,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &g, nil
+	guests := []Guest{g}
+	if err := attachGuestDetails(guests); err != nil {
+		return nil, err
+	}
+
+	return &guests[0], nil
 }
 
 // RecordAttendanceStatus records overall attendance status when no guests are present
@@ -249,17 +427,29 @@ func RecordAttendanceStatus(email string, attending bool) error {
 		return err
 	}
 
-	_, err = result.LastInsertId()
-	return err
+	if _, err := result.LastInsertId(); err != nil {
+		return err
+	}
+
+	return bumpRSVPVersion(email)
 }
 
 // RemovePrimaryContactGuest removes the auto-generated "Primary Contact" guest entry
 // if it exists for the given invitation
 func RemovePrimaryContactGuest(email string) error {
-	_, err := db.DB.Exec(`
+	result, err := db.DB.Exec(`
 		DELETE FROM guests 
 		WHERE invitation_email = ? AND name = 'Primary Contact'
 	`, email)
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Only a removal changes the guest set; loading the form must not invalidate a partner's
+	rows, err := result.RowsAffected()
+	if err != nil || rows == 0 {
+		return err
+	}
+
+	return bumpRSVPVersion(email)
 }
\ No newline at end of file
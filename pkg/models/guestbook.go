@@ -0,0 +1,205 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"wedding-invite/pkg/db"
+)
+
+// Moderation statuses of a guestbook entry
+const (
+	GuestbookPending  = "pending"
+	GuestbookApproved = "approved"
+	GuestbookRejected = "rejected"
+)
+
+// Length limits of a guestbook entry, in characters
+const (
+	MaxGuestbookName    = 80
+	MaxGuestbookMessage = 1000
+)
+
+// ErrGuestbookTooLong is returned when the name or message is over its length limit
+var ErrGuestbookTooLong = errors.New("guestbook entry is too long")
+
+// GuestbookEntry is a message an invitation left in the guestbook
+type GuestbookEntry struct {
+	ID              int64
+	InvitationEmail string
+	AuthorName      string
+	Message         string
+	// Language is the language the message is written in, "en" or "ro"
+	Language  string
+	Status    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ErrControlCharacters is returned when guest input holds control characters other than line breaks
+var ErrControlCharacters = errors.New("text contains control characters")
+
+// hasControlCharacters reports whether text holds control characters other than the allowed ones
+func hasControlCharacters(text, allowed string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsControl(r) && !strings.ContainsRune(allowed, r)
+	}) >= 0
+}
+
+// cleanGuestbookEntry trims and checks the fields of an entry. The text is otherwise stored as
+// submitted; it is escaped wherever it is shown.
+func cleanGuestbookEntry(name, message, language string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	if name == "" || message == "" {
+		return "", "", fmt.Errorf("name and message are required")
+	}
+	if hasControlCharacters(name, "") || hasControlCharacters(message, "\n\t") {
+		return "", "", ErrControlCharacters
+	}
+	if utf8.RuneCountInString(name) > MaxGuestbookName || utf8.RuneCountInString(message) > MaxGuestbookMessage {
+		return "", "", ErrGuestbookTooLong
+	}
+	if language != "en" && language != "ro" {
+		return "", "", fmt.Errorf("unknown language %q", language)
+	}
+	return name, message, nil
+}
+
+// CreateGuestbookEntry leaves a message from the invitation, to be shown once approved
+func CreateGuestbookEntry(email, name, message, language string) error {
+	name, message, err := cleanGuestbookEntry(name, message, language)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO guestbook_entries (invitation_email, author_name, message, language)
+		VALUES (?, ?, ?, ?)
+	`, email, name, message, language)
+
+	return err
+}
+
+// UpdateGuestbookEntry changes one of the invitation's messages. The edit goes back to the
+// moderation queue, so an approved message is hidden until it is approved again.
+func UpdateGuestbookEntry(id int64, email, name, message, language string) error {
+	name, message, err := cleanGuestbookEntry(name, message, language)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE guestbook_entries
+		SET author_name = ?, message = ?, language = ?, status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND invitation_email = ?
+	`, name, message, language, GuestbookPending, id, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("guestbook entry not found or not authorized")
+	}
+
+	return nil
+}
+
+// DeleteGuestbookEntry removes one of the invitation's messages
+func DeleteGuestbookEntry(id int64, email string) error {
+	result, err := db.DB.Exec(`
+		DELETE FROM guestbook_entries
+		WHERE id = ? AND invitation_email = ?
+	`, id, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("guestbook entry not found or not authorized")
+	}
+
+	return nil
+}
+
+// SetGuestbookStatus moderates a guestbook entry
+func SetGuestbookStatus(id int64, status string) error {
+	if status != GuestbookPending && status != GuestbookApproved && status != GuestbookRejected {
+		return fmt.Errorf("unknown guestbook status %q", status)
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE guestbook_entries
+		SET status = ?
+		WHERE id = ?
+	`, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("guestbook entry not found")
+	}
+
+	return nil
+}
+
+// queryGuestbookEntries returns entries oldest first, filtered by an optional condition
+func queryGuestbookEntries(where string, args ...interface{}) ([]GuestbookEntry, error) {
+	query := `
+		SELECT id, invitation_email, author_name, message, language, status, created_at, updated_at
+		FROM guestbook_entries
+	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []GuestbookEntry
+	for rows.Next() {
+		var e GuestbookEntry
+		if err := rows.Scan(&e.ID, &e.InvitationEmail, &e.AuthorName, &e.Message, &e.Language, &e.Status,
+			&e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetApprovedGuestbookEntries returns the messages shown in the guestbook
+func GetApprovedGuestbookEntries() ([]GuestbookEntry, error) {
+	return queryGuestbookEntries("status = ?", GuestbookApproved)
+}
+
+// GetInvitationGuestbookEntries returns the invitation's own messages, whatever their status
+func GetInvitationGuestbookEntries(email string) ([]GuestbookEntry, error) {
+	return queryGuestbookEntries("invitation_email = ?", email)
+}
+
+// GetGuestbookEntries returns every message, for moderation and the keepsake export
+func GetGuestbookEntries() ([]GuestbookEntry, error) {
+	return queryGuestbookEntries("")
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCleanGuestbookEntry(t *testing.T) {
+	for _, tc := range []struct {
+		name, author, message string
+		wantAuthor            string
+		wantMessage           string
+		wantErr               error
+	}{
+		{"trimmed", "  Ana  ", "\n Congratulations! \n", "Ana", "Congratulations!", nil},
+		{"markup is kept as written", "Ana <3", "Use <b>bold</b> &amp; be happy", "Ana <3", "Use <b>bold</b> &amp; be happy", nil},
+		{"line breaks are normalized", "Ana", "Dear both,\r\n\r\nLove", "Ana", "Dear both,\n\nLove", nil},
+		{"control characters are rejected", "Ana", "Hi\x00there", "", "", ErrControlCharacters},
+		{"no line breaks in the name", "Ana\nMaria", "Hi", "", "", ErrControlCharacters},
+		{"too long", "Ana", strings.Repeat("ă", MaxGuestbookMessage+1), "", "", ErrGuestbookTooLong},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			author, message, err := cleanGuestbookEntry(tc.author, tc.message, "en")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tc.wantErr)
+			}
+			if author != tc.wantAuthor || message != tc.wantMessage {
+				t.Errorf("got %q, %q, want %q, %q", author, message, tc.wantAuthor, tc.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,469 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Statuses of a request for hotel rooms
+const (
+	RoomRequestPending   = "pending"
+	RoomRequestConfirmed = "confirmed"
+	RoomRequestDeclined  = "declined"
+)
+
+// DateInputFormat is the layout of dates in forms
+const DateInputFormat = "2006-01-02"
+
+// Hotel is a hotel where rooms are held for wedding guests. Hotels with coordinates are also
+// shown on the travel page.
+type Hotel struct {
+	ID        int64
+	Name      string
+	Address   string
+	Website   string
+	Latitude  sql.NullFloat64
+	Longitude sql.NullFloat64
+	// NotesEN and NotesRO are written in Markdown
+	NotesEN string
+	NotesRO string
+	Blocks  []RoomBlock
+}
+
+// RoomBlock is a number of rooms of one type a hotel holds for guests between two dates
+type RoomBlock struct {
+	ID          int64
+	HotelID     int64
+	RoomTypeEN  string
+	RoomTypeRO  string
+	Rooms       int
+	CheckIn     time.Time
+	CheckOut    time.Time
+	NightlyRate int
+	// Confirmed and Pending count the rooms of confirmed and unanswered requests
+	Confirmed int
+	Pending   int
+}
+
+// RoomType returns the block's room type in the given language
+func (b RoomBlock) RoomType(lang string) string {
+	if lang == "ro" {
+		return b.RoomTypeRO
+	}
+	return b.RoomTypeEN
+}
+
+// Remaining returns the number of rooms in the block not yet allocated to guests
+func (b RoomBlock) Remaining() int {
+	if b.Confirmed >= b.Rooms {
+		return 0
+	}
+	return b.Rooms - b.Confirmed
+}
+
+// RoomRequest is an invitation's request for rooms from a block
+type RoomRequest struct {
+	ID              int64
+	InvitationEmail string
+	BlockID         int64
+	Rooms           int
+	CheckIn         time.Time
+	CheckOut        time.Time
+	Notes           string
+	Status          string
+	CreatedAt       time.Time
+	DecidedAt       sql.NullTime
+	// HotelName, RoomTypeEN and RoomTypeRO describe the requested block
+	HotelName  string
+	RoomTypeEN string
+	RoomTypeRO string
+}
+
+// RoomType returns the requested room type in the given language
+func (rr RoomRequest) RoomType(lang string) string {
+	if lang == "ro" {
+		return rr.RoomTypeRO
+	}
+	return rr.RoomTypeEN
+}
+
+// Nights returns the number of nights requested
+func (rr RoomRequest) Nights() int {
+	return nightsBetween(rr.CheckIn, rr.CheckOut)
+}
+
+// nightsBetween counts the nights between a check-in and a check-out date
+func nightsBetween(checkIn, checkOut time.Time) int {
+	return int(checkOut.Sub(checkIn).Hours() / 24)
+}
+
+// ParseDate parses a date given in DateInputFormat
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateInputFormat, value)
+}
+
+// GetHotels retrieves every hotel with its room blocks and their allocation
+func GetHotels() ([]Hotel, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, name, address, website, latitude, longitude, notes_en, notes_ro
+		FROM hotels
+		ORDER BY name, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hotels []Hotel
+	index := make(map[int64]int)
+
+	for rows.Next() {
+		var h Hotel
+		if err := rows.Scan(&h.ID, &h.Name, &h.Address, &h.Website, &h.Latitude, &h.Longitude, &h.NotesEN, &h.NotesRO); err != nil {
+			return nil, err
+		}
+		index[h.ID] = len(hotels)
+		hotels = append(hotels, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	blocks, err := db.DB.Query(`
+		SELECT id, hotel_id, room_type_en, room_type_ro, rooms, check_in, check_out, nightly_rate,
+		       (SELECT COALESCE(SUM(rooms), 0) FROM room_requests WHERE block_id = room_blocks.id AND status = ?),
+		       (SELECT COALESCE(SUM(rooms), 0) FROM room_requests WHERE block_id = room_blocks.id AND status = ?)
+		FROM room_blocks
+		ORDER BY check_in, id
+	`, RoomRequestConfirmed, RoomRequestPending)
+	if err != nil {
+		return nil, err
+	}
+	defer blocks.Close()
+
+	for blocks.Next() {
+		var b RoomBlock
+		if err := blocks.Scan(
+			&b.ID,
+			&b.HotelID,
+			&b.RoomTypeEN,
+			&b.RoomTypeRO,
+			&b.Rooms,
+			&b.CheckIn,
+			&b.CheckOut,
+			&b.NightlyRate,
+			&b.Confirmed,
+			&b.Pending,
+		); err != nil {
+			return nil, err
+		}
+		if i, ok := index[b.HotelID]; ok {
+			hotels[i].Blocks = append(hotels[i].Blocks, b)
+		}
+	}
+
+	if err := blocks.Err(); err != nil {
+		return nil, err
+	}
+
+	return hotels, nil
+}
+
+// validateHotel checks the fields of a hotel. Coordinates are optional, but come in pairs.
+func validateHotel(name, website string, latitude, longitude sql.NullFloat64) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("a name is required")
+	}
+	if latitude.Valid != longitude.Valid {
+		return fmt.Errorf("both coordinates are required to show the hotel on the map")
+	}
+	if latitude.Valid {
+		if err := validateCoordinates(latitude.Float64, longitude.Float64); err != nil {
+			return err
+		}
+	}
+	return validateWebsite(website)
+}
+
+// CreateHotel adds a hotel
+func CreateHotel(name, address, website string, latitude, longitude sql.NullFloat64, notesEN, notesRO string) error {
+	if err := validateHotel(name, website, latitude, longitude); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO hotels (name, address, website, latitude, longitude, notes_en, notes_ro)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, strings.TrimSpace(name), strings.TrimSpace(address), strings.TrimSpace(website), latitude, longitude,
+		strings.TrimSpace(notesEN), strings.TrimSpace(notesRO))
+
+	return err
+}
+
+// UpdateHotel changes a hotel's details
+func UpdateHotel(id int64, name, address, website string, latitude, longitude sql.NullFloat64, notesEN, notesRO string) error {
+	if err := validateHotel(name, website, latitude, longitude); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE hotels
+		SET name = ?, address = ?, website = ?, latitude = ?, longitude = ?, notes_en = ?, notes_ro = ?
+		WHERE id = ?
+	`, strings.TrimSpace(name), strings.TrimSpace(address), strings.TrimSpace(website), latitude, longitude,
+		strings.TrimSpace(notesEN), strings.TrimSpace(notesRO), id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("hotel not found")
+	}
+
+	return nil
+}
+
+// validateRoomBlock checks the fields of a room block
+func validateRoomBlock(roomTypeEN, roomTypeRO string, rooms int, checkIn, checkOut time.Time, nightlyRate int) error {
+	if strings.TrimSpace(roomTypeEN) == "" || strings.TrimSpace(roomTypeRO) == "" {
+		return fmt.Errorf("room types are required in every language")
+	}
+	if rooms < 0 {
+		return fmt.Errorf("rooms cannot be negative")
+	}
+	if !checkOut.After(checkIn) {
+		return fmt.Errorf("check-out must be after check-in")
+	}
+	if nightlyRate < 0 {
+		return fmt.Errorf("the rate cannot be negative")
+	}
+	return nil
+}
+
+// CreateRoomBlock adds a block of rooms to a hotel
+func CreateRoomBlock(hotelID int64, roomTypeEN, roomTypeRO string, rooms int, checkIn, checkOut time.Time, nightlyRate int) error {
+	if err := validateRoomBlock(roomTypeEN, roomTypeRO, rooms, checkIn, checkOut, nightlyRate); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO room_blocks (hotel_id, room_type_en, room_type_ro, rooms, check_in, check_out, nightly_rate)
+		SELECT id, ?, ?, ?, ?, ?, ? FROM hotels
+		WHERE id = ?
+	`, strings.TrimSpace(roomTypeEN), strings.TrimSpace(roomTypeRO), rooms, checkIn, checkOut, nightlyRate, hotelID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("hotel not found")
+	}
+
+	return nil
+}
+
+// UpdateRoomBlock changes a room block. Lowering the rooms below those confirmed keeps the
+// confirmed requests but stops new confirmations.
+func UpdateRoomBlock(id int64, roomTypeEN, roomTypeRO string, rooms int, checkIn, checkOut time.Time, nightlyRate int) error {
+	if err := validateRoomBlock(roomTypeEN, roomTypeRO, rooms, checkIn, checkOut, nightlyRate); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE room_blocks
+		SET room_type_en = ?, room_type_ro = ?, rooms = ?, check_in = ?, check_out = ?, nightly_rate = ?
+		WHERE id = ?
+	`, strings.TrimSpace(roomTypeEN), strings.TrimSpace(roomTypeRO), rooms, checkIn, checkOut, nightlyRate, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("room block not found")
+	}
+
+	return nil
+}
+
+// CreateRoomRequest records an invitation's request for rooms from a block, for nights within the block's dates
+func CreateRoomRequest(email string, blockID int64, rooms int, checkIn, checkOut time.Time, notes string) error {
+	if rooms < 1 {
+		return fmt.Errorf("at least one room must be requested")
+	}
+	if !checkOut.After(checkIn) {
+		return fmt.Errorf("check-out must be after check-in")
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO room_requests (invitation_email, block_id, rooms, check_in, check_out, notes, status, created_at)
+		SELECT ?, id, ?, ?, ?, ?, ?, ? FROM room_blocks
+		WHERE id = ? AND check_in <= ? AND check_out >= ?
+	`, email, rooms, checkIn, checkOut, strings.TrimSpace(notes), RoomRequestPending, time.Now(), blockID, checkIn, checkOut)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("room block not found or dates outside the block")
+	}
+
+	return nil
+}
+
+// queryRoomRequests retrieves room requests matching the given condition, newest first
+func queryRoomRequests(where string, args ...interface{}) ([]RoomRequest, error) {
+	rows, err := db.DB.Query(`
+		SELECT rr.id, rr.invitation_email, rr.block_id, rr.rooms, rr.check_in, rr.check_out,
+		       rr.notes, rr.status, rr.created_at, rr.decided_at,
+		       h.name, b.room_type_en, b.room_type_ro
+		FROM room_requests rr
+		JOIN room_blocks b ON b.id = rr.block_id
+		JOIN hotels h ON h.id = b.hotel_id
+		`+where+`
+		ORDER BY rr.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []RoomRequest
+
+	for rows.Next() {
+		var rr RoomRequest
+		if err := rows.Scan(
+			&rr.ID,
+			&rr.InvitationEmail,
+			&rr.BlockID,
+			&rr.Rooms,
+			&rr.CheckIn,
+			&rr.CheckOut,
+			&rr.Notes,
+			&rr.Status,
+			&rr.CreatedAt,
+			&rr.DecidedAt,
+			&rr.HotelName,
+			&rr.RoomTypeEN,
+			&rr.RoomTypeRO,
+		); err != nil {
+			return nil, err
+		}
+		requests = append(requests, rr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// GetInvitationRoomRequests retrieves the room requests of an invitation
+func GetInvitationRoomRequests(email string) ([]RoomRequest, error) {
+	return queryRoomRequests("WHERE rr.invitation_email = ?", email)
+}
+
+// GetRoomRequests retrieves every room request
+func GetRoomRequests() ([]RoomRequest, error) {
+	return queryRoomRequests("")
+}
+
+// ConfirmRoomRequest allocates rooms to a pending request. The block's remaining rooms are
+// checked in the same statement that confirms it, so a block is never overbooked.
+func ConfirmRoomRequest(id int64) error {
+	result, err := db.DB.Exec(`
+		UPDATE room_requests
+		SET status = ?, decided_at = ?
+		WHERE id = ? AND status = ?
+		  AND rooms <= (SELECT rooms FROM room_blocks WHERE id = room_requests.block_id)
+		             - (SELECT COALESCE(SUM(rooms), 0) FROM room_requests confirmed
+		                WHERE confirmed.block_id = room_requests.block_id AND confirmed.status = ?)
+	`, RoomRequestConfirmed, time.Now(), id, RoomRequestPending, RoomRequestConfirmed)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("room request not pending or not enough rooms left")
+	}
+
+	return nil
+}
+
+// DeclineRoomRequest declines a pending request, or releases the rooms of a confirmed one
+func DeclineRoomRequest(id int64) error {
+	result, err := db.DB.Exec(`
+		UPDATE room_requests
+		SET status = ?, decided_at = ?
+		WHERE id = ? AND status IN (?, ?)
+	`, RoomRequestDeclined, time.Now(), id, RoomRequestPending, RoomRequestConfirmed)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("room request not found or already declined")
+	}
+
+	return nil
+}
+
+// GetRoomingList retrieves a hotel and the confirmed room requests it should expect, by check-in date
+func GetRoomingList(hotelID int64) (*Hotel, []RoomRequest, error) {
+	var h Hotel
+	err := db.DB.QueryRow(`
+		SELECT id, name, address, website
+		FROM hotels
+		WHERE id = ?
+	`, hotelID).Scan(&h.ID, &h.Name, &h.Address, &h.Website)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requests, err := queryRoomRequests("WHERE h.id = ? AND rr.status = ?", hotelID, RoomRequestConfirmed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Hotels work through arrivals in date order
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CheckIn.Before(requests[j].CheckIn)
+	})
+
+	return &h, requests, nil
+}
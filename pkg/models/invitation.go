@@ -0,0 +1,150 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"wedding-invite/pkg/db"
+)
+
+// InvitationAllowance describes who an invitation may bring: the guests invited
+// by name and the number of open plus-one slots the invitee fills in
+type InvitationAllowance struct {
+	Email       string
+	MaxGuests   int
+	PlusOnes    int
+	NamedGuests []Guest
+}
+
+// GetInvitationAllowances retrieves the allowance of every invitation
+func GetInvitationAllowances() ([]InvitationAllowance, error) {
+	rows, err := db.DB.Query(`
+		SELECT email, max_guests, plus_ones
+		FROM invitations
+		ORDER BY email
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allowances []InvitationAllowance
+	index := make(map[string]int)
+
+	for rows.Next() {
+		var a InvitationAllowance
+		if err := rows.Scan(&a.Email, &a.MaxGuests, &a.PlusOnes); err != nil {
+			return nil, err
+		}
+
+		index[a.Email] = len(allowances)
+		allowances = append(allowances, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	guests, err := GetAllGuests()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range guests {
+		if i, ok := index[g.InvitationEmail]; ok && g.Named {
+			allowances[i].NamedGuests = append(allowances[i].NamedGuests, g)
+		}
+	}
+
+	return allowances, nil
+}
+
+// SetInvitationLanguage remembers the language the invitee uses the site in, to email them in it
+func SetInvitationLanguage(email, language string) error {
+	if language != "en" && language != "ro" {
+		return fmt.Errorf("unknown language %q", language)
+	}
+
+	_, err := db.DB.Exec(`
+		UPDATE invitations
+		SET language = ?
+		WHERE email = ?
+	`, language, email)
+
+	return err
+}
+
+// GetInvitationLanguage retrieves the language the invitee last used the site in
+func GetInvitationLanguage(email string) (string, error) {
+	var language string
+	err := db.DB.QueryRow(`
+		SELECT language FROM invitations
+		WHERE email = ?
+	`, email).Scan(&language)
+
+	return language, err
+}
+
+// AddNamedGuest invites a guest by name on behalf of an invitation
+func AddNamedGuest(email, name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("a name is required")
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO guests (invitation_email, name, named)
+		SELECT email, ?, TRUE FROM invitations
+		WHERE email = ?
+	`, name, email)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rows == 0 {
+		return 0, fmt.Errorf("invitation not found")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, bumpRSVPVersion(email)
+}
+
+// RemoveNamedGuest removes a guest an admin invited by name
+func RemoveNamedGuest(id int64, email string) error {
+	return deleteGuest(id, email, true)
+}
+
+// SetPlusOnes sets how many guests of their choice an invitation may bring besides its named guests
+func SetPlusOnes(email string, plusOnes int) error {
+	if plusOnes < 0 {
+		return fmt.Errorf("plus-ones cannot be negative")
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE invitations
+		SET plus_ones = ?
+		WHERE email = ?
+	`, plusOnes, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("invitation not found")
+	}
+
+	return nil
+}
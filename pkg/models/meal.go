@@ -0,0 +1,241 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"wedding-invite/pkg/db"
+)
+
+// MealOption is a menu choice guests can pick. Guests store the Key, which
+// never changes, so renaming or retiring an option keeps existing answers intact.
+type MealOption struct {
+	ID           int64
+	Key          string
+	LabelEN      string
+	LabelRO      string
+	SortOrder    int
+	Active       bool
+	ChildrenOnly bool
+}
+
+// Label returns the option's label in the given language
+func (m MealOption) Label(lang string) string {
+	if lang == "ro" {
+		return m.LabelRO
+	}
+	return m.LabelEN
+}
+
+// ErrMealOptionExists is returned when a new menu option's key is already taken
+var ErrMealOptionExists = errors.New("a menu option with this key already exists, please try again")
+
+// defaultMealOptions seeds the menu the first time the site starts
+var defaultMealOptions = []MealOption{
+	{Key: "Standard", LabelEN: "Standard", LabelRO: "Standard"},
+	{Key: "Vegetarian", LabelEN: "Vegetarian", LabelRO: "Vegetarian"},
+	{Key: "Ovo-Lacto Vegetarian", LabelEN: "Ovo-Lacto Vegetarian", LabelRO: "Ovo-Lacto Vegetarian"},
+	{Key: "Ovo-Lacto with Fish", LabelEN: "Ovo-Lacto with Fish", LabelRO: "Ovo-Lacto cu Pește"},
+	{Key: "Muslim", LabelEN: "Muslim", LabelRO: "Musulman"},
+	{Key: "Gluten-Free", LabelEN: "Gluten Free", LabelRO: "Fără Gluten"},
+	{Key: "Lactose-Free", LabelEN: "Lactose Free", LabelRO: "Fără Lactoză"},
+	{Key: "Child", LabelEN: "Child", LabelRO: "Copil", ChildrenOnly: true},
+}
+
+// mealLabels caches every option by key so labels can be looked up while rendering
+var (
+	mealLabelsMu sync.RWMutex
+	mealLabels   = map[string]MealOption{}
+)
+
+// InitializeMealOptions seeds the default menu if it is empty and loads the label cache
+func InitializeMealOptions() error {
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM meal_options`).Scan(&count); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		for i, option := range defaultMealOptions {
+			_, err := db.DB.Exec(`
+				INSERT INTO meal_options (key, label_en, label_ro, sort_order, active, children_only)
+				VALUES (?, ?, ?, ?, TRUE, ?)
+			`, option.Key, option.LabelEN, option.LabelRO, (i+1)*10, option.ChildrenOnly)
+			if err != nil {
+				return fmt.Errorf("failed to seed meal option %s: %w", option.Key, err)
+			}
+		}
+	}
+
+	return reloadMealLabels()
+}
+
+// reloadMealLabels refreshes the label cache from the database
+func reloadMealLabels() error {
+	options, err := GetAllMealOptions()
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[string]MealOption, len(options))
+	for _, option := range options {
+		labels[option.Key] = option
+	}
+
+	mealLabelsMu.Lock()
+	mealLabels = labels
+	mealLabelsMu.Unlock()
+
+	return nil
+}
+
+// MealLabel returns the label of a meal option in the given language,
+// falling back to the stored key for values that were never an option
+func MealLabel(lang, key string) string {
+	mealLabelsMu.RLock()
+	option, ok := mealLabels[key]
+	mealLabelsMu.RUnlock()
+
+	if !ok {
+		return key
+	}
+	return option.Label(lang)
+}
+
+// queryMealOptions retrieves meal options matching the given condition, in menu order
+func queryMealOptions(where string) ([]MealOption, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, key, label_en, label_ro, sort_order, active, children_only
+		FROM meal_options
+		` + where + `
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []MealOption
+
+	for rows.Next() {
+		var m MealOption
+		if err := rows.Scan(
+			&m.ID,
+			&m.Key,
+			&m.LabelEN,
+			&m.LabelRO,
+			&m.SortOrder,
+			&m.Active,
+			&m.ChildrenOnly,
+		); err != nil {
+			return nil, err
+		}
+
+		options = append(options, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// GetActiveMealOptions retrieves the options guests can currently choose from
+func GetActiveMealOptions() ([]MealOption, error) {
+	return queryMealOptions("WHERE active")
+}
+
+// GetAllMealOptions retrieves every option, including retired ones
+func GetAllMealOptions() ([]MealOption, error) {
+	return queryMealOptions("")
+}
+
+// CreateMealOption adds a new menu option with a generated permanent key, so that
+// options with the same English label can coexist and labels can be reused later
+func CreateMealOption(labelEN, labelRO string, sortOrder int, childrenOnly bool) error {
+	labelEN = strings.TrimSpace(labelEN)
+	labelRO = strings.TrimSpace(labelRO)
+	if labelEN == "" || labelRO == "" {
+		return fmt.Errorf("labels are required in every language")
+	}
+
+	key, err := newMealOptionKey(labelEN)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO meal_options (key, label_en, label_ro, sort_order, active, children_only)
+		VALUES (?, ?, ?, ?, TRUE, ?)
+	`, key, labelEN, labelRO, sortOrder, childrenOnly)
+	if db.IsUniqueViolation(err) {
+		return ErrMealOptionExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return reloadMealLabels()
+}
+
+// newMealOptionKey builds a key from the English label followed by a random suffix,
+// e.g. "fish-menu-3fa91c0e"
+func newMealOptionKey(labelEN string) (string, error) {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(labelEN) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteByte('-')
+		}
+	}
+	if slug.Len() == 0 {
+		slug.WriteString("meal-")
+	} else if !strings.HasSuffix(slug.String(), "-") {
+		slug.WriteByte('-')
+	}
+
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return slug.String() + hex.EncodeToString(b), nil
+}
+
+// UpdateMealOption renames, reorders, retires or reactivates a menu option. The key is never changed.
+func UpdateMealOption(id int64, labelEN, labelRO string, sortOrder int, active, childrenOnly bool) error {
+	labelEN = strings.TrimSpace(labelEN)
+	labelRO = strings.TrimSpace(labelRO)
+	if labelEN == "" || labelRO == "" {
+		return fmt.Errorf("labels are required in every language")
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE meal_options
+		SET label_en = ?,
+		    label_ro = ?,
+		    sort_order = ?,
+		    active = ?,
+		    children_only = ?
+		WHERE id = ?
+	`, labelEN, labelRO, sortOrder, active, childrenOnly, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("meal option not found")
+	}
+
+	return reloadMealLabels()
+}
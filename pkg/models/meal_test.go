@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateMealOptionKeys(t *testing.T) {
+	setupTestDB(t)
+
+	for i := 0; i < 2; i++ {
+		if err := CreateMealOption("Fish Menu", "Meniu de pește", 100, false); err != nil {
+			t.Fatalf("CreateMealOption #%d: %v", i+1, err)
+		}
+	}
+
+	options, err := GetAllMealOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, option := range options {
+		if option.LabelEN == "Fish Menu" {
+			keys = append(keys, option.Key)
+		}
+	}
+	if len(keys) != 2 || keys[0] == keys[1] {
+		t.Fatalf("expected two options with distinct keys, got %v", keys)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "fish-menu-") {
+			t.Errorf("key %q does not start with the label's slug", key)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// setupTestDB points db.DB at a fresh database for the duration of a test
+func setupTestDB(t *testing.T) {
+	t.Helper()
+
+	// Skip syncing to disk, which would make each test take seconds
+	t.Setenv("DB_PATH", filepath.Join(t.TempDir(), "wedding.db")+"?_sync=OFF&_journal=MEMORY")
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+	t.Cleanup(db.Close)
+}
+
+// createTestInvitation adds an approved invitation
+func createTestInvitation(t *testing.T, email string) {
+	t.Helper()
+
+	if _, err := db.DB.Exec(`INSERT INTO invitations (email, approved) VALUES (?, TRUE)`, email); err != nil {
+		t.Fatalf("failed to create invitation %s: %v", email, err)
+	}
+}
+
+// createTestGuest adds a guest to an invitation with the given answer, last changed at updated
+func createTestGuest(t *testing.T, email, name string, attending sql.NullBool, updated time.Time) int64 {
+	t.Helper()
+
+	result, err := db.DB.Exec(`
+		INSERT INTO guests (invitation_email, name, attending, last_updated)
+		VALUES (?, ?, ?, ?)
+	`, email, name, attending, updated)
+	if err != nil {
+		t.Fatalf("failed to create guest %s: %v", name, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
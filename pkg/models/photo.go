@@ -0,0 +1,250 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"wedding-invite/pkg/db"
+	"wedding-invite/pkg/imaging"
+	"wedding-invite/pkg/storage"
+)
+
+// Moderation statuses of a guest photo
+const (
+	PhotoPending  = "pending"
+	PhotoApproved = "approved"
+	PhotoRejected = "rejected"
+)
+
+const (
+	// MaxPhotoSize is the largest photo file guests can upload, in bytes
+	MaxPhotoSize = 15 << 20
+	// MaxPhotosPerInvitation is how many photos one invitation can upload
+	MaxPhotosPerInvitation = 50
+	// MaxPhotosPerUpload is how many photos one upload form submission can carry
+	MaxPhotosPerUpload = 10
+	// PhotoThumbnailSize is the longest side of photo thumbnails, in pixels
+	PhotoThumbnailSize = 480
+)
+
+var (
+	// ErrPhotoQuota is returned when the invitation already uploaded MaxPhotosPerInvitation photos
+	ErrPhotoQuota = errors.New("photo quota reached")
+	// ErrPhotoTooLarge is returned for files over MaxPhotoSize
+	ErrPhotoTooLarge = errors.New("photo file is too large")
+)
+
+// GuestPhoto is a photo a guest uploaded, stored without its location metadata
+type GuestPhoto struct {
+	ID              int64
+	InvitationEmail string
+	FileKey         string
+	ThumbnailKey    string
+	ContentType     string
+	Size            int64
+	Width           int
+	Height          int
+	Status          string
+	CreatedAt       time.Time
+}
+
+// newStorageKey returns an unguessable key for a new file in a storage folder
+func newStorageKey(folder, suffix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return folder + "/" + hex.EncodeToString(b) + suffix, nil
+}
+
+// UploadGuestPhoto stores a photo from the invitation for moderation. The file is checked to
+// be a JPEG or PNG image, stripped of GPS data, and given a JPEG thumbnail.
+func UploadGuestPhoto(email string, data []byte) error {
+	if len(data) > MaxPhotoSize {
+		return ErrPhotoTooLarge
+	}
+
+	// Refuse early when the quota is already used up, before processing the image
+	var count int
+	if err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM guest_photos WHERE invitation_email = ?
+	`, email).Scan(&count); err != nil {
+		return err
+	}
+	if count >= MaxPhotosPerInvitation {
+		return ErrPhotoQuota
+	}
+
+	cleaned, contentType, orientation, err := imaging.Sanitize(data)
+	if err != nil {
+		return err
+	}
+	width, height, err := imaging.Size(cleaned, orientation)
+	if err != nil {
+		return err
+	}
+	small, err := imaging.Decode(cleaned, orientation, PhotoThumbnailSize)
+	if err != nil {
+		return err
+	}
+	thumbnail, err := imaging.EncodeJPEG(small, 80)
+	if err != nil {
+		return err
+	}
+
+	suffix := ".jpg"
+	if contentType == "image/png" {
+		suffix = ".png"
+	}
+	fileKey, err := newStorageKey("guest-photos", suffix)
+	if err != nil {
+		return err
+	}
+	thumbnailKey, err := newStorageKey("guest-photos/thumbnails", ".jpg")
+	if err != nil {
+		return err
+	}
+
+	if err := storage.Uploads.Save(fileKey, bytes.NewReader(cleaned)); err != nil {
+		return err
+	}
+	if err := storage.Uploads.Save(thumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		deletePhotoFiles(fileKey)
+		return err
+	}
+
+	// Check the quota again in the insert itself, so concurrent uploads cannot go over it
+	result, err := db.DB.Exec(`
+		INSERT INTO guest_photos (invitation_email, file_key, thumbnail_key, content_type, size, width, height)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM guest_photos WHERE invitation_email = ?) < ?
+	`, email, fileKey, thumbnailKey, contentType, len(cleaned), width, height, email, MaxPhotosPerInvitation)
+	if err != nil {
+		deletePhotoFiles(fileKey, thumbnailKey)
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		deletePhotoFiles(fileKey, thumbnailKey)
+		return err
+	}
+	if rows == 0 {
+		deletePhotoFiles(fileKey, thumbnailKey)
+		return ErrPhotoQuota
+	}
+
+	return nil
+}
+
+// deletePhotoFiles removes stored files, logging failures since the photo is gone either way
+func deletePhotoFiles(keys ...string) {
+	for _, key := range keys {
+		if err := storage.Uploads.Delete(key); err != nil {
+			log.Printf("Error deleting stored file %s: %v", key, err)
+		}
+	}
+}
+
+// DeleteGuestPhoto removes a photo and its files. Guests pass their invitation email to
+// delete their own photos; admins pass an empty email to delete any photo.
+func DeleteGuestPhoto(id int64, email string) error {
+	photo, err := GetGuestPhoto(id)
+	if err != nil {
+		return err
+	}
+	if photo == nil || (email != "" && photo.InvitationEmail != email) {
+		return fmt.Errorf("photo not found or not authorized")
+	}
+
+	if _, err := db.DB.Exec(`DELETE FROM guest_photos WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	deletePhotoFiles(photo.FileKey, photo.ThumbnailKey)
+	return nil
+}
+
+// SetGuestPhotoStatus moderates a guest photo
+func SetGuestPhotoStatus(id int64, status string) error {
+	if status != PhotoPending && status != PhotoApproved && status != PhotoRejected {
+		return fmt.Errorf("unknown photo status %q", status)
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE guest_photos
+		SET status = ?
+		WHERE id = ?
+	`, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("photo not found")
+	}
+
+	return nil
+}
+
+// queryGuestPhotos returns photos newest first, filtered by an optional condition
+func queryGuestPhotos(where string, args ...interface{}) ([]GuestPhoto, error) {
+	query := `
+		SELECT id, invitation_email, file_key, thumbnail_key, content_type, size, width, height, status, created_at
+		FROM guest_photos
+	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []GuestPhoto
+	for rows.Next() {
+		var p GuestPhoto
+		if err := rows.Scan(&p.ID, &p.InvitationEmail, &p.FileKey, &p.ThumbnailKey, &p.ContentType, &p.Size,
+			&p.Width, &p.Height, &p.Status, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		photos = append(photos, p)
+	}
+
+	return photos, rows.Err()
+}
+
+// GetGuestPhoto returns a photo by ID, or nil if there is none
+func GetGuestPhoto(id int64) (*GuestPhoto, error) {
+	photos, err := queryGuestPhotos("id = ?", id)
+	if err != nil || len(photos) == 0 {
+		return nil, err
+	}
+	return &photos[0], nil
+}
+
+// GetApprovedGuestPhotos returns the photos every guest can see
+func GetApprovedGuestPhotos() ([]GuestPhoto, error) {
+	return queryGuestPhotos("status = ?", PhotoApproved)
+}
+
+// GetInvitationGuestPhotos returns the invitation's own uploads, whatever their status
+func GetInvitationGuestPhotos(email string) ([]GuestPhoto, error) {
+	return queryGuestPhotos("invitation_email = ?", email)
+}
+
+// GetGuestPhotos returns every uploaded photo, for moderation
+func GetGuestPhotos() ([]GuestPhoto, error) {
+	return queryGuestPhotos("")
+}
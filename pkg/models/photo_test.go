@@ -0,0 +1,84 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+	"wedding-invite/pkg/db"
+	"wedding-invite/pkg/storage"
+)
+
+func TestUploadGuestPhotoQuota(t *testing.T) {
+	setupTestDB(t)
+	createTestInvitation(t, "a@example.com")
+
+	dir := t.TempDir()
+	disk, err := storage.NewLocalDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := storage.Uploads
+	storage.Uploads = disk
+	t.Cleanup(func() { storage.Uploads = previous })
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave room for a single photo
+	for i := 0; i < MaxPhotosPerInvitation-1; i++ {
+		if _, err := db.DB.Exec(`
+			INSERT INTO guest_photos (invitation_email, file_key, thumbnail_key, content_type, size, width, height)
+			VALUES (?, 'old.png', 'old.jpg', 'image/png', 1, 1, 1)
+		`, "a@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Concurrent uploads all pass the early check; only one may take the last place
+	const uploads = 4
+	errs := make([]error, uploads)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = UploadGuestPhoto("a@example.com", buf.Bytes())
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrPhotoQuota):
+			t.Fatalf("UploadGuestPhoto: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d uploads succeeded, want 1", succeeded)
+	}
+
+	// Refused uploads leave no files behind: one photo and its thumbnail remain
+	var files int
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 2 {
+		t.Errorf("%d files stored, want 2", files)
+	}
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"wedding-invite/pkg/db"
+)
+
+// Inbox states of a guest question
+const (
+	QuestionUnread   = "unread"
+	QuestionRead     = "read"
+	QuestionAnswered = "answered"
+)
+
+// QuestionStatuses lists the inbox states in the order admins work through them
+var QuestionStatuses = []string{QuestionUnread, QuestionRead, QuestionAnswered}
+
+// Limits of guest questions and replies; lengths are in characters
+const (
+	MaxQuestionSubject = 150
+	MaxQuestionMessage = 2000
+	MaxQuestionReply   = 4000
+	// MaxOpenQuestions is how many unanswered questions an invitation may have at once
+	MaxOpenQuestions = 5
+)
+
+var (
+	// ErrQuestionTooLong is returned when the subject, message or reply is over its length limit
+	ErrQuestionTooLong = errors.New("question is too long")
+	// ErrTooManyQuestions is returned when the invitation already has MaxOpenQuestions unanswered
+	ErrTooManyQuestions = errors.New("too many unanswered questions")
+)
+
+// GuestQuestion is a question an invitation sent the couple through the contact form
+type GuestQuestion struct {
+	ID              int64
+	InvitationEmail string
+	Subject         string
+	Message         string
+	// Language is the language the guest was using, which replies are emailed in
+	Language  string
+	Status    string
+	CreatedAt time.Time
+	Replies   []QuestionReply
+}
+
+// QuestionReply is the couple's answer to a guest question
+type QuestionReply struct {
+	ID         int64
+	QuestionID int64
+	Body       string
+	// EmailedAt is when the reply was emailed to the guest; it is not set when sending failed
+	EmailedAt sql.NullTime
+	CreatedAt time.Time
+}
+
+// CreateGuestQuestion stores a question from the invitation and returns it
+func CreateGuestQuestion(email, subject, message, language string) (*GuestQuestion, error) {
+	subject = strings.TrimSpace(subject)
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	if subject == "" || message == "" {
+		return nil, fmt.Errorf("subject and message are required")
+	}
+	if hasControlCharacters(subject, "") || hasControlCharacters(message, "\n\t") {
+		return nil, ErrControlCharacters
+	}
+	if utf8.RuneCountInString(subject) > MaxQuestionSubject || utf8.RuneCountInString(message) > MaxQuestionMessage {
+		return nil, ErrQuestionTooLong
+	}
+	if language != "en" && language != "ro" {
+		return nil, fmt.Errorf("unknown language %q", language)
+	}
+
+	var open int
+	if err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM guest_questions
+		WHERE invitation_email = ? AND status != ?
+	`, email, QuestionAnswered).Scan(&open); err != nil {
+		return nil, err
+	}
+	if open >= MaxOpenQuestions {
+		return nil, ErrTooManyQuestions
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO guest_questions (invitation_email, subject, message, language, status)
+		VALUES (?, ?, ?, ?, ?)
+	`, email, subject, message, language, QuestionUnread)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return GetGuestQuestion(id)
+}
+
+// SetGuestQuestionStatus moves a question between the inbox states
+func SetGuestQuestionStatus(id int64, status string) error {
+	valid := false
+	for _, s := range QuestionStatuses {
+		valid = valid || s == status
+	}
+	if !valid {
+		return fmt.Errorf("unknown question status %q", status)
+	}
+
+	result, err := db.DB.Exec(`UPDATE guest_questions SET status = ? WHERE id = ?`, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("question not found")
+	}
+
+	return nil
+}
+
+// ReplyToGuestQuestion stores the couple's answer and marks the question answered. It returns
+// the new reply's ID, to record once the reply has been emailed.
+func ReplyToGuestQuestion(id int64, body string) (int64, error) {
+	body = strings.TrimSpace(strings.ReplaceAll(body, "\r\n", "\n"))
+	if body == "" {
+		return 0, fmt.Errorf("reply is required")
+	}
+	if utf8.RuneCountInString(body) > MaxQuestionReply {
+		return 0, ErrQuestionTooLong
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE guest_questions SET status = ? WHERE id = ?`, QuestionAnswered, id)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, fmt.Errorf("question not found")
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO guest_question_replies (question_id, body)
+		VALUES (?, ?)
+	`, id, body)
+	if err != nil {
+		return 0, err
+	}
+
+	replyID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return replyID, tx.Commit()
+}
+
+// SetQuestionReplyEmailed records that a reply has been emailed to the guest
+func SetQuestionReplyEmailed(id int64) error {
+	_, err := db.DB.Exec(`UPDATE guest_question_replies SET emailed_at = ? WHERE id = ?`, time.Now(), id)
+	return err
+}
+
+// queryGuestQuestions returns questions with their replies, newest first, filtered by an optional condition
+func queryGuestQuestions(where string, args ...interface{}) ([]GuestQuestion, error) {
+	query := `
+		SELECT id, invitation_email, subject, message, language, status, created_at
+		FROM guest_questions
+	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []GuestQuestion
+	index := make(map[int64]int)
+	for rows.Next() {
+		var q GuestQuestion
+		if err := rows.Scan(&q.ID, &q.InvitationEmail, &q.Subject, &q.Message, &q.Language, &q.Status, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[q.ID] = len(questions)
+		questions = append(questions, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return questions, nil
+	}
+
+	replyRows, err := db.DB.Query(`
+		SELECT id, question_id, body, emailed_at, created_at
+		FROM guest_question_replies
+		ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer replyRows.Close()
+
+	for replyRows.Next() {
+		var reply QuestionReply
+		if err := replyRows.Scan(&reply.ID, &reply.QuestionID, &reply.Body, &reply.EmailedAt, &reply.CreatedAt); err != nil {
+			return nil, err
+		}
+		if i, ok := index[reply.QuestionID]; ok {
+			questions[i].Replies = append(questions[i].Replies, reply)
+		}
+	}
+
+	return questions, replyRows.Err()
+}
+
+// GetGuestQuestion returns a question with its replies, or nil if there is none with that ID
+func GetGuestQuestion(id int64) (*GuestQuestion, error) {
+	questions, err := queryGuestQuestions("id = ?", id)
+	if err != nil || len(questions) == 0 {
+		return nil, err
+	}
+	return &questions[0], nil
+}
+
+// GetInvitationGuestQuestions returns the invitation's own questions with the couple's replies
+func GetInvitationGuestQuestions(email string) ([]GuestQuestion, error) {
+	return queryGuestQuestions("invitation_email = ?", email)
+}
+
+// GetGuestQuestions returns every question for the admin inbox
+func GetGuestQuestions() ([]GuestQuestion, error) {
+	return queryGuestQuestions("")
+}
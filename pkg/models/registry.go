@@ -0,0 +1,327 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Kinds of registry entry
+const (
+	// RegistryGift is an item or experience one invitation reserves in full
+	RegistryGift = "gift"
+	// RegistryFund collects pledges of any amount from many invitations, like the honeymoon fund
+	RegistryFund = "fund"
+)
+
+// ErrGiftReserved is returned when someone else already reserved the gift
+var ErrGiftReserved = errors.New("gift is already reserved")
+
+// RegistryItem is an entry of the gift registry. Amounts are whole RON.
+type RegistryItem struct {
+	ID            int64
+	Kind          string
+	TitleEN       string
+	TitleRO       string
+	DescriptionEN string
+	DescriptionRO string
+	TargetAmount  int
+	Active        bool
+	// Pledged is the total pledged so far; a reserved gift counts its full target amount
+	Pledged int
+}
+
+// Title returns the entry's title in the given language
+func (i RegistryItem) Title(lang string) string {
+	if lang == "ro" {
+		return i.TitleRO
+	}
+	return i.TitleEN
+}
+
+// Description returns the entry's description in the given language
+func (i RegistryItem) Description(lang string) string {
+	if lang == "ro" {
+		return i.DescriptionRO
+	}
+	return i.DescriptionEN
+}
+
+// IsReserved reports whether the entry is a gift someone has reserved
+func (i RegistryItem) IsReserved() bool {
+	return i.Kind == RegistryGift && i.Pledged > 0
+}
+
+// Progress returns the pledged share of the target amount as a percentage, at most 100
+func (i RegistryItem) Progress() int {
+	if i.TargetAmount <= 0 || i.Pledged >= i.TargetAmount {
+		return 100
+	}
+	return i.Pledged * 100 / i.TargetAmount
+}
+
+// RegistryPledge is an invitation's reservation of a gift or pledge to a fund
+type RegistryPledge struct {
+	ID              int64
+	ItemID          int64
+	InvitationEmail string
+	Amount          int
+	Message         string
+	CreatedAt       time.Time
+	// ThankedAt is when the couple sent their thank-you note
+	ThankedAt sql.NullTime
+	// Kind, TitleEN and TitleRO describe the registry entry
+	Kind    string
+	TitleEN string
+	TitleRO string
+}
+
+// Title returns the title of the pledged entry in the given language
+func (p RegistryPledge) Title(lang string) string {
+	if lang == "ro" {
+		return p.TitleRO
+	}
+	return p.TitleEN
+}
+
+// validateRegistryItem checks the details of a registry entry
+func validateRegistryItem(kind, titleEN, titleRO string, targetAmount int) error {
+	if kind != RegistryGift && kind != RegistryFund {
+		return fmt.Errorf("unknown registry kind %q", kind)
+	}
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return fmt.Errorf("titles are required in both languages")
+	}
+	if targetAmount < 1 {
+		return fmt.Errorf("target amount must be positive")
+	}
+	return nil
+}
+
+// GetRegistryItems returns the registry with the amount pledged to each entry, optionally
+// only the entries still offered to guests
+func GetRegistryItems(activeOnly bool) ([]RegistryItem, error) {
+	query := `
+		SELECT i.id, i.kind, i.title_en, i.title_ro, i.description_en, i.description_ro,
+		       i.target_amount, i.active, COALESCE(SUM(p.amount), 0)
+		FROM registry_items i
+		LEFT JOIN registry_pledges p ON p.item_id = i.id
+	`
+	if activeOnly {
+		query += ` WHERE i.active = TRUE`
+	}
+	query += ` GROUP BY i.id ORDER BY i.kind, i.id`
+
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []RegistryItem
+	for rows.Next() {
+		var i RegistryItem
+		if err := rows.Scan(&i.ID, &i.Kind, &i.TitleEN, &i.TitleRO, &i.DescriptionEN, &i.DescriptionRO,
+			&i.TargetAmount, &i.Active, &i.Pledged); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+// CreateRegistryItem adds an entry to the registry
+func CreateRegistryItem(kind, titleEN, titleRO, descriptionEN, descriptionRO string, targetAmount int) error {
+	if err := validateRegistryItem(kind, titleEN, titleRO, targetAmount); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO registry_items (kind, title_en, title_ro, description_en, description_ro, target_amount)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, kind, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO),
+		strings.TrimSpace(descriptionEN), strings.TrimSpace(descriptionRO), targetAmount)
+
+	return err
+}
+
+// UpdateRegistryItem changes an entry. Its kind is fixed once created, since existing
+// pledges were made on its terms; retiring it hides it from guests but keeps its pledges.
+func UpdateRegistryItem(id int64, titleEN, titleRO, descriptionEN, descriptionRO string, targetAmount int, active bool) error {
+	var kind string
+	if err := db.DB.QueryRow(`SELECT kind FROM registry_items WHERE id = ?`, id).Scan(&kind); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("registry item not found")
+		}
+		return err
+	}
+	if err := validateRegistryItem(kind, titleEN, titleRO, targetAmount); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		UPDATE registry_items
+		SET title_en = ?, title_ro = ?, description_en = ?, description_ro = ?,
+		    target_amount = ?, active = ?
+		WHERE id = ?
+	`, strings.TrimSpace(titleEN), strings.TrimSpace(titleRO),
+		strings.TrimSpace(descriptionEN), strings.TrimSpace(descriptionRO), targetAmount, active, id)
+
+	return err
+}
+
+// ReserveGift reserves an active gift for the invitation. It fails with ErrGiftReserved
+// when anyone already reserved it.
+func ReserveGift(itemID int64, email, message string) error {
+	// Check and insert in one statement so two guests cannot reserve the same gift
+	result, err := db.DB.Exec(`
+		INSERT INTO registry_pledges (item_id, invitation_email, amount, message)
+		SELECT id, ?, target_amount, ?
+		FROM registry_items
+		WHERE id = ? AND kind = ? AND active = TRUE
+		  AND NOT EXISTS (SELECT 1 FROM registry_pledges WHERE item_id = ?)
+	`, email, strings.TrimSpace(message), itemID, RegistryGift, itemID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		var exists bool
+		if err := db.DB.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM registry_items WHERE id = ? AND kind = ? AND active = TRUE)
+		`, itemID, RegistryGift).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("gift not found")
+		}
+		return ErrGiftReserved
+	}
+
+	return nil
+}
+
+// PledgeToFund records the invitation's pledge of an amount to an active fund
+func PledgeToFund(itemID int64, email string, amount int, message string) error {
+	if amount < 1 {
+		return fmt.Errorf("pledge must be positive")
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO registry_pledges (item_id, invitation_email, amount, message)
+		SELECT id, ?, ?, ?
+		FROM registry_items
+		WHERE id = ? AND kind = ? AND active = TRUE
+	`, email, amount, strings.TrimSpace(message), itemID, RegistryFund)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("fund not found")
+	}
+
+	return nil
+}
+
+// CancelPledge withdraws one of the invitation's reservations or pledges
+func CancelPledge(id int64, email string) error {
+	result, err := db.DB.Exec(`
+		DELETE FROM registry_pledges
+		WHERE id = ? AND invitation_email = ?
+	`, id, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("pledge not found or not authorized")
+	}
+
+	return nil
+}
+
+// queryRegistryPledges returns pledges with their entry's details, filtered by an optional condition
+func queryRegistryPledges(where string, args ...interface{}) ([]RegistryPledge, error) {
+	query := `
+		SELECT p.id, p.item_id, p.invitation_email, p.amount, p.message, p.created_at, p.thanked_at,
+		       i.kind, i.title_en, i.title_ro
+		FROM registry_pledges p
+		JOIN registry_items i ON i.id = p.item_id
+	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY p.created_at, p.id"
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pledges []RegistryPledge
+	for rows.Next() {
+		var p RegistryPledge
+		if err := rows.Scan(&p.ID, &p.ItemID, &p.InvitationEmail, &p.Amount, &p.Message, &p.CreatedAt,
+			&p.ThankedAt, &p.Kind, &p.TitleEN, &p.TitleRO); err != nil {
+			return nil, err
+		}
+		pledges = append(pledges, p)
+	}
+
+	return pledges, rows.Err()
+}
+
+// GetInvitationPledges returns the invitation's own reservations and pledges
+func GetInvitationPledges(email string) ([]RegistryPledge, error) {
+	return queryRegistryPledges("p.invitation_email = ?", email)
+}
+
+// GetRegistryPledges returns every reservation and pledge, for the couple's thank-you notes
+func GetRegistryPledges() ([]RegistryPledge, error) {
+	return queryRegistryPledges("")
+}
+
+// SetPledgeThanked records whether the thank-you note for a pledge has been sent
+func SetPledgeThanked(id int64, thanked bool) error {
+	var thankedAt interface{}
+	if thanked {
+		thankedAt = time.Now()
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE registry_pledges
+		SET thanked_at = ?
+		WHERE id = ?
+	`, thankedAt, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("pledge not found")
+	}
+
+	return nil
+}
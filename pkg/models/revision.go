@@ -0,0 +1,334 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Kinds of change between two RSVP revisions
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeUpdated = "updated"
+)
+
+// Guest fields compared between RSVP revisions
+const (
+	FieldName               = "name"
+	FieldAttending          = "attending"
+	FieldMeal               = "meal"
+	FieldDietary            = "dietary"
+	FieldAllergens          = "allergens"
+	FieldAge                = "age"
+	FieldAccessibility      = "accessibility"
+	FieldAccessibilityNotes = "accessibility_notes"
+	FieldAgeYears           = "age_years"
+	FieldNamed              = "named"
+	FieldShuttles           = "shuttles"
+	FieldVenue              = "venue"
+)
+
+// RevisionGuest is a guest as it was recorded in an RSVP revision
+type RevisionGuest struct {
+	ID                  int64    `json:"id"`
+	Name                string   `json:"name"`
+	Attending           *bool    `json:"attending"`
+	MealPreference      string   `json:"meal_preference"`
+	DietaryRestrictions string   `json:"dietary_restrictions"`
+	Allergens           []string `json:"allergens,omitempty"`
+	AgeCategory         string   `json:"age_category,omitempty"`
+	// Courses maps each course to the ID of the chosen course choice
+	Courses map[string]int64 `json:"courses,omitempty"`
+	// AccessibilityNeeds and AccessibilityNotes record the assistance the guest needs
+	AccessibilityNeeds []string `json:"accessibility_needs,omitempty"`
+	AccessibilityNotes string   `json:"accessibility_notes,omitempty"`
+	Age                *int64   `json:"age,omitempty"`
+	Named              bool     `json:"named,omitempty"`
+	// Shuttles lists the IDs of the shuttle runs the guest had a seat on
+	Shuttles []int64 `json:"shuttles,omitempty"`
+	// VenueStatus is empty for guests that were not attending
+	VenueStatus string `json:"venue_status,omitempty"`
+}
+
+// RSVPRevision is an immutable snapshot of a whole party after an RSVP submission
+type RSVPRevision struct {
+	ID              int64
+	InvitationEmail string
+	CreatedAt       time.Time
+	Guests          []RevisionGuest
+}
+
+// RevisionChange describes a single difference between two RSVP revisions.
+// Course choices are reported with the course key as the field and the choice ID as the values.
+type RevisionChange struct {
+	Kind      string
+	GuestName string
+	Field     string
+	Old       string
+	New       string
+}
+
+// RevisionEntry is an RSVP revision together with what changed since the previous one
+type RevisionEntry struct {
+	Revision RSVPRevision
+	Changes  []RevisionChange
+}
+
+// InvitationRevisions summarizes the RSVP history of an invitation
+type InvitationRevisions struct {
+	Email       string
+	Count       int
+	LastChanged time.Time
+}
+
+// RecordRSVPRevision stores a snapshot of the invitation's current guests
+func RecordRSVPRevision(email string) error {
+	guests, err := GetGuestsByInvitation(email)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make([]RevisionGuest, 0, len(guests))
+	for _, g := range guests {
+		rg := RevisionGuest{
+			ID:                  g.ID,
+			Name:                g.Name,
+			MealPreference:      g.MealPreference.String,
+			DietaryRestrictions: g.DietaryRestrictions.String,
+			Allergens:           g.Allergens,
+			AgeCategory:         g.AgeCategory,
+			Courses:             g.Courses,
+			AccessibilityNeeds:  g.AccessibilityNeeds,
+			AccessibilityNotes:  g.AccessibilityNotes.String,
+			Named:               g.Named,
+			Shuttles:            g.Shuttles,
+			VenueStatus:         g.VenueStatus,
+		}
+		if g.Attending.Valid {
+			attending := g.Attending.Bool
+			rg.Attending = &attending
+		}
+		if g.Age.Valid {
+			age := g.Age.Int64
+			rg.Age = &age
+		}
+		snapshot = append(snapshot, rg)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO rsvp_revisions (invitation_email, created_at, snapshot)
+		VALUES (?, ?, ?)
+	`, email, time.Now(), string(data))
+
+	return err
+}
+
+// GetRSVPTimeline retrieves every revision of an invitation, newest first,
+// each with the changes compared to the revision before it
+func GetRSVPTimeline(email string) ([]RevisionEntry, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, invitation_email, created_at, snapshot
+		FROM rsvp_revisions
+		WHERE invitation_email = ?
+		ORDER BY id
+	`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []RSVPRevision
+
+	for rows.Next() {
+		var rev RSVPRevision
+		var snapshot string
+		if err := rows.Scan(&rev.ID, &rev.InvitationEmail, &rev.CreatedAt, &snapshot); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(snapshot), &rev.Guests); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	timeline := make([]RevisionEntry, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		var previous []RevisionGuest
+		if i > 0 {
+			previous = revisions[i-1].Guests
+		}
+
+		timeline = append(timeline, RevisionEntry{
+			Revision: revisions[i],
+			Changes:  DiffRevisionGuests(previous, revisions[i].Guests),
+		})
+	}
+
+	return timeline, nil
+}
+
+// GetAllInvitationRevisions summarizes the RSVP history of every invitation that submitted one
+func GetAllInvitationRevisions() ([]InvitationRevisions, error) {
+	// Join back to the latest revision so created_at is scanned as a timestamp
+	rows, err := db.DB.Query(`
+		SELECT r.invitation_email, c.revision_count, r.created_at
+		FROM rsvp_revisions r
+		JOIN (
+			SELECT invitation_email, COUNT(*) AS revision_count, MAX(id) AS last_id
+			FROM rsvp_revisions
+			GROUP BY invitation_email
+		) c ON c.last_id = r.id
+		ORDER BY r.id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []InvitationRevisions
+
+	for rows.Next() {
+		var s InvitationRevisions
+		if err := rows.Scan(&s.Email, &s.Count, &s.LastChanged); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// revisionField is a guest field compared between two revisions
+type revisionField struct {
+	name     string
+	old, new string
+}
+
+// DiffRevisionGuests lists the changes between two snapshots of a party, matching guests by ID
+func DiffRevisionGuests(previous, current []RevisionGuest) []RevisionChange {
+	var changes []RevisionChange
+
+	previousByID := make(map[int64]RevisionGuest, len(previous))
+	for _, g := range previous {
+		previousByID[g.ID] = g
+	}
+
+	currentIDs := make(map[int64]bool, len(current))
+	for _, g := range current {
+		currentIDs[g.ID] = true
+
+		old, existed := previousByID[g.ID]
+		if !existed {
+			changes = append(changes, RevisionChange{Kind: ChangeAdded, GuestName: g.Name})
+			continue
+		}
+
+		fields := []revisionField{
+			{FieldName, old.Name, g.Name},
+			{FieldAttending, formatAttending(old.Attending), formatAttending(g.Attending)},
+			{FieldMeal, old.MealPreference, g.MealPreference},
+			{FieldDietary, old.DietaryRestrictions, g.DietaryRestrictions},
+			{FieldAllergens, strings.Join(old.Allergens, ","), strings.Join(g.Allergens, ",")},
+			{FieldAge, revisionAgeCategory(old), revisionAgeCategory(g)},
+			{FieldAccessibility, strings.Join(old.AccessibilityNeeds, ","), strings.Join(g.AccessibilityNeeds, ",")},
+			{FieldAccessibilityNotes, old.AccessibilityNotes, g.AccessibilityNotes},
+			{FieldAgeYears, formatRevisionAge(old.Age), formatRevisionAge(g.Age)},
+			{FieldNamed, strconv.FormatBool(old.Named), strconv.FormatBool(g.Named)},
+			{FieldShuttles, formatShuttleIDs(old.Shuttles), formatShuttleIDs(g.Shuttles)},
+			{FieldVenue, old.VenueStatus, g.VenueStatus},
+		}
+		for _, course := range Courses {
+			fields = append(fields, revisionField{
+				course,
+				formatCourseChoice(old.Courses[course]),
+				formatCourseChoice(g.Courses[course]),
+			})
+		}
+		for _, f := range fields {
+			if f.old != f.new {
+				changes = append(changes, RevisionChange{
+					Kind:      ChangeUpdated,
+					GuestName: g.Name,
+					Field:     f.name,
+					Old:       f.old,
+					New:       f.new,
+				})
+			}
+		}
+	}
+
+	for _, g := range previous {
+		if !currentIDs[g.ID] {
+			changes = append(changes, RevisionChange{Kind: ChangeRemoved, GuestName: g.Name})
+		}
+	}
+
+	return changes
+}
+
+// formatAttending converts an attendance answer to "true", "false" or "" when unanswered
+func formatAttending(attending *bool) string {
+	if attending == nil {
+		return ""
+	}
+	if *attending {
+		return "true"
+	}
+	return "false"
+}
+
+// formatCourseChoice converts a course choice ID to a string, or "" when nothing was chosen
+func formatCourseChoice(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// formatRevisionAge converts a recorded age to a string, or "" when no age was given
+func formatRevisionAge(age *int64) string {
+	if age == nil {
+		return ""
+	}
+	return strconv.FormatInt(*age, 10)
+}
+
+// formatShuttleIDs joins shuttle run IDs in ascending order so the same bookings always compare equal
+func formatShuttleIDs(ids []int64) string {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	parts := make([]string, len(sorted))
+	for i, id := range sorted {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// revisionAgeCategory returns the guest's age category; revisions recorded
+// before age categories existed only had adults
+func revisionAgeCategory(g RevisionGuest) string {
+	if g.AgeCategory == "" {
+		return AgeAdult
+	}
+	return g.AgeCategory
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+func TestDiffRevisionGuests(t *testing.T) {
+	yes, no := true, false
+	ana := RevisionGuest{ID: 1, Name: "Ana", Attending: &yes, MealPreference: "beef"}
+	mihai := RevisionGuest{ID: 2, Name: "Mihai"}
+	seven := int64(7)
+
+	for _, tc := range []struct {
+		name     string
+		previous []RevisionGuest
+		current  []RevisionGuest
+		want     []RevisionChange
+	}{
+		{"first revision", nil, []RevisionGuest{ana, mihai}, []RevisionChange{
+			{Kind: ChangeAdded, GuestName: "Ana"},
+			{Kind: ChangeAdded, GuestName: "Mihai"},
+		}},
+		{"no changes", []RevisionGuest{ana, mihai}, []RevisionGuest{ana, mihai}, nil},
+		{"guest removed", []RevisionGuest{ana, mihai}, []RevisionGuest{ana}, []RevisionChange{
+			{Kind: ChangeRemoved, GuestName: "Mihai"},
+		}},
+		{"answered attendance", []RevisionGuest{mihai}, []RevisionGuest{{ID: 2, Name: "Mihai", Attending: &no}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Mihai", Field: FieldAttending, Old: "", New: "false"},
+		}},
+		{"renamed guest is matched by ID", []RevisionGuest{ana}, []RevisionGuest{{ID: 1, Name: "Ana Maria", Attending: &yes, MealPreference: "fish"}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Ana Maria", Field: FieldName, Old: "Ana", New: "Ana Maria"},
+			{Kind: ChangeUpdated, GuestName: "Ana Maria", Field: FieldMeal, Old: "beef", New: "fish"},
+		}},
+		{"same name, different guest", []RevisionGuest{mihai}, []RevisionGuest{{ID: 3, Name: "Mihai"}}, []RevisionChange{
+			{Kind: ChangeAdded, GuestName: "Mihai"},
+			{Kind: ChangeRemoved, GuestName: "Mihai"},
+		}},
+		{"dietary notes cleared", []RevisionGuest{{ID: 1, Name: "Ana", DietaryRestrictions: "no nuts"}}, []RevisionGuest{{ID: 1, Name: "Ana"}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Ana", Field: FieldDietary, Old: "no nuts", New: ""},
+		}},
+		{"age given", []RevisionGuest{mihai}, []RevisionGuest{{ID: 2, Name: "Mihai", Age: &seven}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Mihai", Field: FieldAgeYears, Old: "", New: "7"},
+		}},
+		{"invited by name", []RevisionGuest{mihai}, []RevisionGuest{{ID: 2, Name: "Mihai", Named: true}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Mihai", Field: FieldNamed, Old: "false", New: "true"},
+		}},
+		{"shuttle order is ignored", []RevisionGuest{{ID: 2, Name: "Mihai", Shuttles: []int64{4, 2}}}, []RevisionGuest{{ID: 2, Name: "Mihai", Shuttles: []int64{2, 4}}}, nil},
+		{"shuttle changed", []RevisionGuest{{ID: 2, Name: "Mihai", Shuttles: []int64{2}}}, []RevisionGuest{{ID: 2, Name: "Mihai", Shuttles: []int64{2, 3}}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Mihai", Field: FieldShuttles, Old: "2", New: "2,3"},
+		}},
+		{"promoted from the waitlist", []RevisionGuest{{ID: 1, Name: "Ana", Attending: &yes, MealPreference: "beef", VenueStatus: VenueWaitlisted}}, []RevisionGuest{{ID: 1, Name: "Ana", Attending: &yes, MealPreference: "beef", VenueStatus: VenueConfirmed}}, []RevisionChange{
+			{Kind: ChangeUpdated, GuestName: "Ana", Field: FieldVenue, Old: VenueWaitlisted, New: VenueConfirmed},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DiffRevisionGuests(tc.previous, tc.current)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("DiffRevisionGuests\n got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordRSVPRevision(t *testing.T) {
+	setupTestDB(t)
+	createTestInvitation(t, "a@example.com")
+	id := createTestGuest(t, "a@example.com", "Ana", sql.NullBool{Bool: true, Valid: true}, time.Now())
+	if _, err := db.DB.Exec(`
+		UPDATE guests SET age = 7, age_category = ?, named = TRUE, venue_status = ? WHERE id = ?
+	`, AgeChild, VenueWaitlisted, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecordRSVPRevision("a@example.com"); err != nil {
+		t.Fatalf("RecordRSVPRevision: %v", err)
+	}
+	timeline, err := GetRSVPTimeline("a@example.com")
+	if err != nil {
+		t.Fatalf("GetRSVPTimeline: %v", err)
+	}
+	if len(timeline) != 1 || len(timeline[0].Revision.Guests) != 1 {
+		t.Fatalf("expected one revision with one guest, got %+v", timeline)
+	}
+
+	got := timeline[0].Revision.Guests[0]
+	if got.Age == nil || *got.Age != 7 || got.AgeCategory != AgeChild || !got.Named || got.VenueStatus != VenueWaitlisted {
+		t.Errorf("snapshot is missing guest details: %+v", got)
+	}
+}
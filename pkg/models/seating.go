@@ -0,0 +1,742 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"wedding-invite/pkg/db"
+)
+
+// Shapes a seating table can have
+const (
+	TableRound       = "round"
+	TableRectangular = "rectangular"
+	TableSquare      = "square"
+)
+
+// TableShapes lists the table shapes in the order admins pick from them
+var TableShapes = []string{TableRound, TableRectangular, TableSquare}
+
+var (
+	// ErrTableFull is returned when guests do not fit in the free seats of a table
+	ErrTableFull = errors.New("not enough free seats at the table")
+	// ErrSeparatedGuests is returned when guests would share a table with someone they are kept apart from
+	ErrSeparatedGuests = errors.New("guests must not be seated together")
+)
+
+// seatedGuestCondition matches the guests who have a place at the reception and need a seat;
+// infants sit on a high chair next to their household instead
+const seatedGuestCondition = "attending = TRUE AND venue_status != '" + VenueWaitlisted + "' AND age_category != '" + AgeInfant + "'"
+
+// SeatedGuest is a guest with their seat at a table
+type SeatedGuest struct {
+	Guest
+	Seat int
+}
+
+// SeatingTable is a table at the reception
+type SeatingTable struct {
+	ID       int64
+	Name     string
+	Shape    string
+	Capacity int
+	// Guests holds the guests seated at the table, by seat number
+	Guests []SeatedGuest
+	// HighChairs holds the infants of the households seated at the table. They do not take up seats.
+	HighChairs []Guest
+}
+
+// FreeSeats returns the number of seats at the table nobody sits on
+func (t SeatingTable) FreeSeats() int {
+	if len(t.Guests) >= t.Capacity {
+		return 0
+	}
+	return t.Capacity - len(t.Guests)
+}
+
+// SeatingSeparation keeps two guests from being seated at the same table
+type SeatingSeparation struct {
+	GuestID        int64
+	GuestName      string
+	OtherGuestID   int64
+	OtherGuestName string
+}
+
+// SeatingPlan is the reception's tables with the guests seated at them
+type SeatingPlan struct {
+	Tables []SeatingTable
+	// Unseated holds the guests with a place at the reception who have no seat yet
+	Unseated    []Guest
+	Separations []SeatingSeparation
+	// Warnings describes the constraints the current plan breaks
+	Warnings []string
+}
+
+// Seated returns the number of guests who have a seat
+func (p SeatingPlan) Seated() int {
+	seated := 0
+	for _, table := range p.Tables {
+		seated += len(table.Guests)
+	}
+	return seated
+}
+
+// Seats returns the number of seats across all tables
+func (p SeatingPlan) Seats() int {
+	seats := 0
+	for _, table := range p.Tables {
+		seats += table.Capacity
+	}
+	return seats
+}
+
+// HighChairs returns the number of high chairs across all tables
+func (p SeatingPlan) HighChairs() int {
+	chairs := 0
+	for _, table := range p.Tables {
+		chairs += len(table.HighChairs)
+	}
+	return chairs
+}
+
+// Guests returns every guest in the plan, seated or not, by name
+func (p SeatingPlan) Guests() []Guest {
+	guests := append([]Guest(nil), p.Unseated...)
+	for _, table := range p.Tables {
+		for _, guest := range table.Guests {
+			guests = append(guests, guest.Guest)
+		}
+	}
+	sort.SliceStable(guests, func(i, j int) bool {
+		return strings.ToLower(guests[i].Name) < strings.ToLower(guests[j].Name)
+	})
+	return guests
+}
+
+// validateSeatingTable checks the details of a table
+func validateSeatingTable(name, shape string, capacity int) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("table name is required")
+	}
+	if capacity < 1 {
+		return fmt.Errorf("a table needs at least one seat")
+	}
+	for _, s := range TableShapes {
+		if s == shape {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown table shape %q", shape)
+}
+
+// CreateSeatingTable adds a table to the seating plan
+func CreateSeatingTable(name, shape string, capacity int) error {
+	if err := validateSeatingTable(name, shape, capacity); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO seating_tables (name, shape, capacity)
+		VALUES (?, ?, ?)
+	`, strings.TrimSpace(name), shape, capacity)
+
+	return err
+}
+
+// UpdateSeatingTable changes a table. Lowering the capacity below the number of guests
+// already seated keeps them at the table; the plan then warns about it.
+func UpdateSeatingTable(id int64, name, shape string, capacity int) error {
+	if err := validateSeatingTable(name, shape, capacity); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE seating_tables
+		SET name = ?, shape = ?, capacity = ?
+		WHERE id = ?
+	`, strings.TrimSpace(name), shape, capacity, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("table not found")
+	}
+
+	return nil
+}
+
+// DeleteSeatingTable removes a table, leaving its guests unseated
+func DeleteSeatingTable(id int64) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM seat_assignments WHERE table_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM seating_tables WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddSeatingSeparation keeps two guests from being seated at the same table
+func AddSeatingSeparation(guestID, otherGuestID int64) error {
+	if guestID == otherGuestID {
+		return fmt.Errorf("a guest cannot be kept apart from themselves")
+	}
+	guestID, otherGuestID = separationKey(guestID, otherGuestID)
+
+	_, err := db.DB.Exec(`
+		INSERT OR IGNORE INTO seating_separations (guest_id, other_guest_id)
+		VALUES (?, ?)
+	`, guestID, otherGuestID)
+
+	return err
+}
+
+// RemoveSeatingSeparation lets two guests be seated together again
+func RemoveSeatingSeparation(guestID, otherGuestID int64) error {
+	guestID, otherGuestID = separationKey(guestID, otherGuestID)
+
+	_, err := db.DB.Exec(`
+		DELETE FROM seating_separations
+		WHERE guest_id = ? AND other_guest_id = ?
+	`, guestID, otherGuestID)
+
+	return err
+}
+
+// separationKey orders a pair of guest IDs the way separations are stored
+func separationKey(guestID, otherGuestID int64) (int64, int64) {
+	if guestID > otherGuestID {
+		return otherGuestID, guestID
+	}
+	return guestID, otherGuestID
+}
+
+// GetSeatingPlan returns the tables, who sits at them, who still needs a seat and the
+// constraints the plan breaks. Only guests with a place at the reception are included;
+// infants are listed as high chairs at their household's table once it has a seat.
+func GetSeatingPlan() (*SeatingPlan, error) {
+	guests, err := GetAllGuests()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT id, name, shape, capacity
+		FROM seating_tables
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plan := &SeatingPlan{}
+	tableIndex := make(map[int64]int)
+	for rows.Next() {
+		var t SeatingTable
+		if err := rows.Scan(&t.ID, &t.Name, &t.Shape, &t.Capacity); err != nil {
+			return nil, err
+		}
+		tableIndex[t.ID] = len(plan.Tables)
+		plan.Tables = append(plan.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	type assignment struct {
+		tableID int64
+		seat    int
+	}
+	assignments := make(map[int64]assignment)
+	rows, err = db.DB.Query(`SELECT guest_id, table_id, seat FROM seat_assignments`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var guestID int64
+		var a assignment
+		if err := rows.Scan(&guestID, &a.tableID, &a.seat); err != nil {
+			return nil, err
+		}
+		assignments[guestID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make(map[int64]string)
+	guestTable := make(map[int64]int64)
+	var infants []Guest
+	for _, guest := range guests {
+		if !guest.Attending.Valid || !guest.Attending.Bool || guest.IsWaitlisted() {
+			continue
+		}
+		if !guest.NeedsSeat() {
+			infants = append(infants, guest)
+			continue
+		}
+		names[guest.ID] = guest.Name
+
+		a, ok := assignments[guest.ID]
+		i, tableExists := tableIndex[a.tableID]
+		if !ok || !tableExists {
+			plan.Unseated = append(plan.Unseated, guest)
+			continue
+		}
+		plan.Tables[i].Guests = append(plan.Tables[i].Guests, SeatedGuest{Guest: guest, Seat: a.seat})
+		guestTable[guest.ID] = a.tableID
+	}
+
+	for i := range plan.Tables {
+		table := &plan.Tables[i]
+		sort.Slice(table.Guests, func(a, b int) bool {
+			return table.Guests[a].Seat < table.Guests[b].Seat
+		})
+		if len(table.Guests) > table.Capacity {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"%s has %d guests but only %d seats.", table.Name, len(table.Guests), table.Capacity))
+		}
+	}
+
+	// Infants get a high chair at the first table their household sits at
+	householdTable := make(map[string]int)
+	for i := len(plan.Tables) - 1; i >= 0; i-- {
+		for _, guest := range plan.Tables[i].Guests {
+			householdTable[guest.InvitationEmail] = i
+		}
+	}
+	for _, infant := range infants {
+		if i, ok := householdTable[infant.InvitationEmail]; ok {
+			plan.Tables[i].HighChairs = append(plan.Tables[i].HighChairs, infant)
+		}
+	}
+
+	// Households seated across more than one table
+	householdTables := make(map[string][]string)
+	var households []string
+	for _, table := range plan.Tables {
+		for _, guest := range table.Guests {
+			email := guest.InvitationEmail
+			tables := householdTables[email]
+			if len(tables) == 0 {
+				households = append(households, email)
+			}
+			if len(tables) == 0 || tables[len(tables)-1] != table.Name {
+				householdTables[email] = append(tables, table.Name)
+			}
+		}
+	}
+	sort.Strings(households)
+	for _, email := range households {
+		if tables := householdTables[email]; len(tables) > 1 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"The %s household is split across %s.", email, strings.Join(tables, ", ")))
+		}
+	}
+
+	separations, err := querySeatingSeparations()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range separations {
+		// Separations involving guests who are no longer coming do not constrain the plan
+		if _, ok := names[s.GuestID]; !ok {
+			continue
+		}
+		if _, ok := names[s.OtherGuestID]; !ok {
+			continue
+		}
+		s.GuestName = names[s.GuestID]
+		s.OtherGuestName = names[s.OtherGuestID]
+		plan.Separations = append(plan.Separations, s)
+
+		tableID, seated := guestTable[s.GuestID]
+		if seated && guestTable[s.OtherGuestID] == tableID {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"%s and %s should not sit together at %s.",
+				s.GuestName, s.OtherGuestName, plan.Tables[tableIndex[tableID]].Name))
+		}
+	}
+
+	return plan, nil
+}
+
+// querySeatingSeparations returns every stored separation
+func querySeatingSeparations() ([]SeatingSeparation, error) {
+	rows, err := db.DB.Query(`
+		SELECT guest_id, other_guest_id
+		FROM seating_separations
+		ORDER BY guest_id, other_guest_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var separations []SeatingSeparation
+	for rows.Next() {
+		var s SeatingSeparation
+		if err := rows.Scan(&s.GuestID, &s.OtherGuestID); err != nil {
+			return nil, err
+		}
+		separations = append(separations, s)
+	}
+
+	return separations, rows.Err()
+}
+
+// seatingState is the seating plan as the writers see it inside a transaction
+type seatingState struct {
+	tx       *sql.Tx
+	capacity map[int64]int
+	// occupants maps each table to the seat numbers taken and who sits on them
+	occupants   map[int64]map[int]int64
+	separations map[[2]int64]bool
+}
+
+// loadSeatingState reads the plan inside tx, first releasing the seats of guests who no
+// longer have a place at the reception
+func loadSeatingState(tx *sql.Tx) (*seatingState, error) {
+	if _, err := tx.Exec(`
+		DELETE FROM seat_assignments
+		WHERE guest_id NOT IN (SELECT id FROM guests WHERE ` + seatedGuestCondition + `)
+		   OR table_id NOT IN (SELECT id FROM seating_tables)
+	`); err != nil {
+		return nil, err
+	}
+
+	s := &seatingState{
+		tx:          tx,
+		capacity:    make(map[int64]int),
+		occupants:   make(map[int64]map[int]int64),
+		separations: make(map[[2]int64]bool),
+	}
+
+	rows, err := tx.Query(`SELECT id, capacity FROM seating_tables`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var capacity int
+		if err := rows.Scan(&id, &capacity); err != nil {
+			return nil, err
+		}
+		s.capacity[id] = capacity
+		s.occupants[id] = make(map[int]int64)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`SELECT guest_id, table_id, seat FROM seat_assignments`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var guestID, tableID int64
+		var seat int
+		if err := rows.Scan(&guestID, &tableID, &seat); err != nil {
+			return nil, err
+		}
+		s.occupants[tableID][seat] = guestID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(`SELECT guest_id, other_guest_id FROM seating_separations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key [2]int64
+		if err := rows.Scan(&key[0], &key[1]); err != nil {
+			return nil, err
+		}
+		s.separations[key] = true
+	}
+
+	return s, rows.Err()
+}
+
+// keptApart reports whether any of guestIDs is separated from another of them or from
+// someone already seated at the table
+func (s *seatingState) keptApart(tableID int64, guestIDs []int64) bool {
+	others := make([]int64, 0, len(s.occupants[tableID])+len(guestIDs))
+	for _, guestID := range s.occupants[tableID] {
+		others = append(others, guestID)
+	}
+	others = append(others, guestIDs...)
+
+	for _, guestID := range guestIDs {
+		for _, other := range others {
+			a, b := separationKey(guestID, other)
+			if s.separations[[2]int64{a, b}] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// freeSeats returns the number of unused seats at the table
+func (s *seatingState) freeSeats(tableID int64) int {
+	free := s.capacity[tableID] - len(s.occupants[tableID])
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// unseat removes guests from whichever table they sit at
+func (s *seatingState) unseat(guestIDs []int64) error {
+	for _, guestID := range guestIDs {
+		if _, err := s.tx.Exec(`DELETE FROM seat_assignments WHERE guest_id = ?`, guestID); err != nil {
+			return err
+		}
+		for _, seats := range s.occupants {
+			for seat, occupant := range seats {
+				if occupant == guestID {
+					delete(seats, seat)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// seat puts a guest on the lowest numbered free seat at the table
+func (s *seatingState) seat(tableID, guestID int64) error {
+	seats := s.occupants[tableID]
+	number := 1
+	for seats[number] != 0 {
+		number++
+	}
+
+	if _, err := s.tx.Exec(`
+		INSERT INTO seat_assignments (guest_id, table_id, seat)
+		VALUES (?, ?, ?)
+	`, guestID, tableID, number); err != nil {
+		return err
+	}
+	seats[number] = guestID
+	return nil
+}
+
+// AssignSeats moves guests to a table, giving each the lowest numbered free seat. A table
+// ID of 0 leaves the guests unseated. The move fails with ErrTableFull when the guests do
+// not fit, or ErrSeparatedGuests when it would seat guests who are kept apart together.
+func AssignSeats(guestIDs []int64, tableID int64) error {
+	if len(guestIDs) == 0 {
+		return fmt.Errorf("no guests to seat")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := loadSeatingState(tx)
+	if err != nil {
+		return err
+	}
+
+	if tableID == 0 {
+		if err := state.unseat(guestIDs); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if _, ok := state.capacity[tableID]; !ok {
+		return fmt.Errorf("table not found")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(guestIDs)), ",")
+	args := make([]interface{}, len(guestIDs))
+	for i, id := range guestIDs {
+		args[i] = id
+	}
+	var seatable int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE id IN (`+placeholders+`) AND `+seatedGuestCondition, args...).Scan(&seatable); err != nil {
+		return err
+	}
+	if seatable != len(guestIDs) {
+		return fmt.Errorf("only guests with a place at the reception can be seated")
+	}
+
+	// Guests already at the table keep their seats
+	var moving []int64
+	for _, guestID := range guestIDs {
+		atTable := false
+		for _, occupant := range state.occupants[tableID] {
+			if occupant == guestID {
+				atTable = true
+				break
+			}
+		}
+		if !atTable {
+			moving = append(moving, guestID)
+		}
+	}
+	if len(moving) == 0 {
+		return nil
+	}
+
+	if err := state.unseat(moving); err != nil {
+		return err
+	}
+	if state.freeSeats(tableID) < len(moving) {
+		return ErrTableFull
+	}
+	if state.keptApart(tableID, moving) {
+		return ErrSeparatedGuests
+	}
+
+	for _, guestID := range moving {
+		if err := state.seat(tableID, guestID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AutoSeat drafts seats for the guests who have none, starting over from empty tables when
+// replace is set. Households are seated together at the table they fit best, largest
+// households first; a household that fits no single table is seated across tables. Guests
+// are never seated with someone they are kept apart from. It returns the number of guests
+// seated; guests left over need more tables or seats.
+func AutoSeat(replace bool) (int, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM seat_assignments`); err != nil {
+			return 0, err
+		}
+	}
+
+	state, err := loadSeatingState(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, invitation_email FROM guests
+		WHERE ` + seatedGuestCondition + `
+		  AND id NOT IN (SELECT guest_id FROM seat_assignments)
+		ORDER BY invitation_email, id
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var households [][]int64
+	var lastEmail string
+	for rows.Next() {
+		var id int64
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return 0, err
+		}
+		if len(households) == 0 || email != lastEmail {
+			households = append(households, nil)
+			lastEmail = email
+		}
+		households[len(households)-1] = append(households[len(households)-1], id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	sort.SliceStable(households, func(i, j int) bool {
+		return len(households[i]) > len(households[j])
+	})
+
+	tableIDs := make([]int64, 0, len(state.capacity))
+	for id := range state.capacity {
+		tableIDs = append(tableIDs, id)
+	}
+	sort.Slice(tableIDs, func(i, j int) bool { return tableIDs[i] < tableIDs[j] })
+
+	// bestTable returns the table where the guests fit with the fewest seats to spare,
+	// preferring the given table when they fit there too
+	bestTable := func(guestIDs []int64, preferred int64) int64 {
+		var best int64
+		for _, id := range tableIDs {
+			free := state.freeSeats(id)
+			if free < len(guestIDs) || state.keptApart(id, guestIDs) {
+				continue
+			}
+			if id == preferred {
+				return id
+			}
+			if best == 0 || free < state.freeSeats(best) {
+				best = id
+			}
+		}
+		return best
+	}
+
+	seated := 0
+	for _, household := range households {
+		if tableID := bestTable(household, 0); tableID != 0 {
+			for _, guestID := range household {
+				if err := state.seat(tableID, guestID); err != nil {
+					return 0, err
+				}
+			}
+			seated += len(household)
+			continue
+		}
+
+		// Split the household, keeping each guest near the previous one where possible
+		var previous int64
+		for _, guestID := range household {
+			tableID := bestTable([]int64{guestID}, previous)
+			if tableID == 0 {
+				continue
+			}
+			if err := state.seat(tableID, guestID); err != nil {
+				return 0, err
+			}
+			previous = tableID
+			seated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return seated, nil
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// seatingTestParty creates the invitations of households, each guest accepting, and the tables
+// by capacity. It returns the guest IDs by name and the table IDs in order.
+func seatingTestParty(t *testing.T, households map[string][]string, capacities ...int) (map[string]int64, []int64) {
+	t.Helper()
+	setupTestDB(t)
+
+	emails := make([]string, 0, len(households))
+	for email := range households {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	accepted := sql.NullBool{Bool: true, Valid: true}
+	guests := make(map[string]int64)
+	for _, email := range emails {
+		createTestInvitation(t, email)
+		for _, name := range households[email] {
+			guests[name] = createTestGuest(t, email, name, accepted, time.Now())
+		}
+	}
+
+	var tables []int64
+	for i, capacity := range capacities {
+		if err := CreateSeatingTable("Table "+string(rune('A'+i)), TableRound, capacity); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, int64(i+1))
+	}
+
+	return guests, tables
+}
+
+// seatingByTable lists who sits at each table of the plan, by table name
+func seatingByTable(t *testing.T) (map[string][]string, *SeatingPlan) {
+	t.Helper()
+
+	plan, err := GetSeatingPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seating := make(map[string][]string)
+	for _, table := range plan.Tables {
+		for _, guest := range table.Guests {
+			seating[table.Name] = append(seating[table.Name], guest.Name)
+		}
+		sort.Strings(seating[table.Name])
+	}
+	return seating, plan
+}
+
+func TestAutoSeat(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		households map[string][]string
+		capacities []int
+		apart      [][2]string
+		seated     int
+		want       map[string][]string
+	}{
+		{
+			name: "households sit together at the tightest table",
+			households: map[string][]string{
+				"a@example.com": {"Ana", "Andrei"},
+				"b@example.com": {"Bianca", "Bogdan", "Barbu"},
+			},
+			capacities: []int{6, 3},
+			seated:     5,
+			want:       map[string][]string{"Table A": {"Ana", "Andrei"}, "Table B": {"Barbu", "Bianca", "Bogdan"}},
+		},
+		{
+			name: "largest households are seated first",
+			households: map[string][]string{
+				"a@example.com": {"Ana"},
+				"b@example.com": {"Bianca", "Bogdan", "Barbu", "Bella"},
+			},
+			capacities: []int{4, 2},
+			seated:     5,
+			want:       map[string][]string{"Table A": {"Barbu", "Bella", "Bianca", "Bogdan"}, "Table B": {"Ana"}},
+		},
+		{
+			name:       "a household too large for any table is split",
+			households: map[string][]string{"c@example.com": {"Cristina", "Costin", "Corina"}},
+			capacities: []int{2, 2},
+			seated:     3,
+			want:       map[string][]string{"Table A": {"Costin", "Cristina"}, "Table B": {"Corina"}},
+		},
+		{
+			name: "guests kept apart sit at different tables",
+			households: map[string][]string{
+				"a@example.com": {"Ana"},
+				"b@example.com": {"Bogdan"},
+			},
+			capacities: []int{4, 4},
+			apart:      [][2]string{{"Ana", "Bogdan"}},
+			seated:     2,
+			want:       map[string][]string{"Table A": {"Ana"}, "Table B": {"Bogdan"}},
+		},
+		{
+			name: "guests left over when the seats run out",
+			households: map[string][]string{
+				"a@example.com": {"Ana", "Andrei"},
+				"b@example.com": {"Bogdan"},
+			},
+			capacities: []int{2},
+			seated:     2,
+			want:       map[string][]string{"Table A": {"Ana", "Andrei"}},
+		},
+		{
+			name: "nobody fits when everyone is kept apart from the only table",
+			households: map[string][]string{
+				"a@example.com": {"Ana"},
+				"b@example.com": {"Bogdan"},
+			},
+			capacities: []int{4},
+			apart:      [][2]string{{"Ana", "Bogdan"}},
+			seated:     1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			guests, _ := seatingTestParty(t, tc.households, tc.capacities...)
+			for _, pair := range tc.apart {
+				if err := AddSeatingSeparation(guests[pair[0]], guests[pair[1]]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			seated, err := AutoSeat(false)
+			if err != nil {
+				t.Fatalf("AutoSeat: %v", err)
+			}
+			if seated != tc.seated {
+				t.Errorf("seated %d guests, want %d", seated, tc.seated)
+			}
+
+			seating, plan := seatingByTable(t)
+			if tc.want != nil {
+				for table, names := range tc.want {
+					if strings.Join(seating[table], ", ") != strings.Join(names, ", ") {
+						t.Errorf("%s seats %v, want %v", table, seating[table], names)
+					}
+				}
+			}
+			for _, warning := range plan.Warnings {
+				// Only splitting a household that fits no table is allowed
+				if !strings.Contains(warning, "household is split") {
+					t.Errorf("AutoSeat broke a constraint: %s", warning)
+				}
+			}
+			if plan.Seated()+len(plan.Unseated) != len(guests) {
+				t.Errorf("%d seated and %d unseated, want %d guests", plan.Seated(), len(plan.Unseated), len(guests))
+			}
+		})
+	}
+}
+
+func TestAutoSeatKeepsOrReplacesSeats(t *testing.T) {
+	guests, tables := seatingTestParty(t, map[string][]string{
+		"a@example.com": {"Ana"},
+		"b@example.com": {"Bogdan"},
+	}, 4, 1)
+
+	if err := AssignSeats([]int64{guests["Ana"]}, tables[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AutoSeat(false); err != nil {
+		t.Fatal(err)
+	}
+	seating, _ := seatingByTable(t)
+	if len(seating["Table B"]) != 1 || seating["Table B"][0] != "Ana" {
+		t.Errorf("AutoSeat moved a seated guest: %v", seating)
+	}
+
+	if _, err := AutoSeat(true); err != nil {
+		t.Fatal(err)
+	}
+	seating, _ = seatingByTable(t)
+	if len(seating["Table A"]) != 1 || len(seating["Table B"]) != 1 {
+		t.Errorf("AutoSeat(true) = %v, want one guest at each table", seating)
+	}
+}
+
+func TestAssignSeats(t *testing.T) {
+	guests, tables := seatingTestParty(t, map[string][]string{
+		"a@example.com": {"Ana", "Andrei"},
+		"b@example.com": {"Bogdan"},
+		"c@example.com": {"Cristina"},
+	}, 2, 2)
+	if err := AddSeatingSeparation(guests["Bogdan"], guests["Cristina"]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB.Exec(`UPDATE guests SET attending = FALSE WHERE id = ?`, guests["Andrei"]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		guests []string
+		table  int64
+		want   error
+	}{
+		{"seat a guest", []string{"Bogdan"}, tables[0], nil},
+		{"seating again keeps the seat", []string{"Bogdan"}, tables[0], nil},
+		{"kept apart", []string{"Cristina"}, tables[0], ErrSeparatedGuests},
+		{"more guests than seats", []string{"Ana", "Bogdan", "Cristina"}, tables[1], ErrTableFull},
+		{"guests kept apart moving together", []string{"Bogdan", "Cristina"}, tables[1], ErrSeparatedGuests},
+		{"not enough free seats", []string{"Ana", "Cristina"}, tables[0], ErrTableFull},
+		{"fills the table", []string{"Ana"}, tables[0], nil},
+		{"table full", []string{"Cristina"}, tables[0], ErrTableFull},
+		{"unseat", []string{"Ana"}, 0, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ids := make([]int64, 0, len(tc.guests))
+			for _, name := range tc.guests {
+				ids = append(ids, guests[name])
+			}
+			if err := AssignSeats(ids, tc.table); !errors.Is(err, tc.want) {
+				t.Errorf("AssignSeats(%v, %d) = %v, want %v", tc.guests, tc.table, err, tc.want)
+			}
+		})
+	}
+
+	if err := AssignSeats([]int64{guests["Andrei"]}, tables[1]); err == nil {
+		t.Error("seated a guest who declined")
+	}
+	if err := AssignSeats([]int64{guests["Ana"]}, 99); err == nil {
+		t.Error("seated a guest at a missing table")
+	}
+
+	seating, _ := seatingByTable(t)
+	if strings.Join(seating["Table A"], ", ") != "Bogdan" {
+		t.Errorf("Table A seats %v, want Bogdan", seating["Table A"])
+	}
+}
+
+func TestSeatingPlanWarnings(t *testing.T) {
+	guests, tables := seatingTestParty(t, map[string][]string{
+		"a@example.com": {"Ana", "Andrei"},
+		"b@example.com": {"Bogdan"},
+	}, 2, 2)
+
+	for name, table := range map[string]int64{"Ana": tables[0], "Bogdan": tables[0], "Andrei": tables[1]} {
+		if err := AssignSeats([]int64{guests[name]}, table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := UpdateSeatingTable(tables[0], "Table A", TableRound, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddSeatingSeparation(guests["Bogdan"], guests["Ana"]); err != nil {
+		t.Fatal(err)
+	}
+
+	_, plan := seatingByTable(t)
+	want := []string{
+		"Table A has 2 guests but only 1 seats.",
+		"The a@example.com household is split across Table A, Table B.",
+		"Ana and Bogdan should not sit together at Table A.",
+	}
+	if strings.Join(plan.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings\n got %q\nwant %q", plan.Warnings, want)
+	}
+	if plan.Seats() != 3 || plan.Seated() != 3 {
+		t.Errorf("%d of %d seats taken, want 3 of 3", plan.Seated(), plan.Seats())
+	}
+}
+
+func TestInfantsGetHighChairs(t *testing.T) {
+	guests, tables := seatingTestParty(t, map[string][]string{
+		"a@example.com": {"Ana", "Andrei", "Ilinca"},
+	}, 2)
+	if err := SetGuestAge(guests["Ilinca"], "a@example.com", AgeInfant, sql.NullInt64{}); err != nil {
+		t.Fatal(err)
+	}
+
+	seated, err := AutoSeat(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seated != 2 {
+		t.Errorf("seated %d guests, want 2", seated)
+	}
+	if err := AssignSeats([]int64{guests["Ilinca"]}, tables[0]); err == nil {
+		t.Error("gave an infant a seat")
+	}
+
+	seating, plan := seatingByTable(t)
+	if strings.Join(seating["Table A"], ", ") != "Ana, Andrei" {
+		t.Errorf("Table A seats %v, want Ana and Andrei", seating["Table A"])
+	}
+	if len(plan.Unseated) != 0 || len(plan.Warnings) != 0 {
+		t.Errorf("infant left unseated %v or broke the plan: %v", plan.Unseated, plan.Warnings)
+	}
+	if chairs := plan.Tables[0].HighChairs; len(chairs) != 1 || chairs[0].Name != "Ilinca" {
+		t.Errorf("Table A high chairs = %v, want Ilinca", chairs)
+	}
+}
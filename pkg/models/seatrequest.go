@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Statuses of a request for extra seats
+const (
+	SeatRequestPending  = "pending"
+	SeatRequestApproved = "approved"
+	SeatRequestRejected = "rejected"
+)
+
+// MaxExtraSeats is the most seats a single request may ask for
+const MaxExtraSeats = 10
+
+// SeatRequest is an invitee's request to bring more guests than the invitation allows
+type SeatRequest struct {
+	ID              int64
+	InvitationEmail string
+	Seats           int
+	Reason          string
+	Status          string
+	Response        sql.NullString
+	CreatedAt       time.Time
+	ResolvedAt      sql.NullTime
+}
+
+// CreateSeatRequest records a request for extra seats. An invitation can only have one pending request.
+func CreateSeatRequest(email string, seats int, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if seats < 1 || seats > MaxExtraSeats {
+		return fmt.Errorf("between 1 and %d seats can be requested", MaxExtraSeats)
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+
+	result, err := db.DB.Exec(`
+		INSERT INTO seat_requests (invitation_email, seats, reason, status, created_at)
+		SELECT ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM seat_requests
+			WHERE invitation_email = ? AND status = ?
+		)
+	`, email, seats, reason, SeatRequestPending, time.Now(), email, SeatRequestPending)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("a request is already pending")
+	}
+
+	return nil
+}
+
+// querySeatRequests retrieves seat requests matching the given condition, newest first
+func querySeatRequests(where string, args ...interface{}) ([]SeatRequest, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, invitation_email, seats, reason, status, response, created_at, resolved_at
+		FROM seat_requests
+		`+where+`
+		ORDER BY id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []SeatRequest
+
+	for rows.Next() {
+		var sr SeatRequest
+		if err := rows.Scan(
+			&sr.ID,
+			&sr.InvitationEmail,
+			&sr.Seats,
+			&sr.Reason,
+			&sr.Status,
+			&sr.Response,
+			&sr.CreatedAt,
+			&sr.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, sr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// GetLatestSeatRequest retrieves the most recent seat request of an invitation, or nil if there is none
+func GetLatestSeatRequest(email string) (*SeatRequest, error) {
+	requests, err := querySeatRequests("WHERE invitation_email = ?", email)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return &requests[0], nil
+}
+
+// GetSeatRequest retrieves a seat request, or nil if there is none with that ID
+func GetSeatRequest(id int64) (*SeatRequest, error) {
+	requests, err := querySeatRequests("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return &requests[0], nil
+}
+
+// GetSeatRequests retrieves every seat request, newest first
+func GetSeatRequests() ([]SeatRequest, error) {
+	return querySeatRequests("")
+}
+
+// ApproveSeatRequest approves a pending request and raises the invitation's guest limit.
+// Invitations with named guests get more plus-one slots; others get a higher max_guests.
+func ApproveSeatRequest(id int64, response string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var email string
+	var seats int
+	err = tx.QueryRow(`
+		SELECT invitation_email, seats FROM seat_requests
+		WHERE id = ? AND status = ?
+	`, id, SeatRequestPending).Scan(&email, &seats)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("seat request not found or already resolved")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := resolveSeatRequest(tx, id, SeatRequestApproved, response); err != nil {
+		return err
+	}
+
+	var named int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE invitation_email = ? AND named
+	`, email).Scan(&named); err != nil {
+		return err
+	}
+
+	if named > 0 {
+		_, err = tx.Exec(`
+			UPDATE invitations
+			SET plus_ones = plus_ones + ?
+			WHERE email = ?
+		`, seats, email)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE invitations
+			SET max_guests = max_guests + ?
+			WHERE email = ?
+		`, seats, email)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectSeatRequest rejects a pending request, keeping the response for the invitee
+func RejectSeatRequest(id int64, response string) error {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return fmt.Errorf("a response is required when rejecting a request")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := resolveSeatRequest(tx, id, SeatRequestRejected, response); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resolveSeatRequest records the outcome of a pending request
+func resolveSeatRequest(tx *sql.Tx, id int64, status, response string) error {
+	response = strings.TrimSpace(response)
+
+	result, err := tx.Exec(`
+		UPDATE seat_requests
+		SET status = ?, response = ?, resolved_at = ?
+		WHERE id = ? AND status = ?
+	`, status, sql.NullString{String: response, Valid: response != ""}, time.Now(), id, SeatRequestPending)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("seat request not found or already resolved")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"database/sql"
+	"wedding-invite/pkg/db"
+)
+
+// getSetting retrieves a site-wide setting, returning fallback if it was never set
+func getSetting(key, fallback string) (string, error) {
+	var value string
+	err := db.DB.QueryRow(`
+		SELECT value FROM settings
+		WHERE key = ?
+	`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// setSetting stores a site-wide setting
+func setSetting(key, value string) error {
+	_, err := db.DB.Exec(`
+		INSERT INTO settings (key, value)
+		VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+
+	return err
+}
@@ -0,0 +1,315 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// ShuttleRun is a shuttle trip guests can book seats on, e.g. from the church to the palace
+type ShuttleRun struct {
+	ID        int64
+	LabelEN   string
+	LabelRO   string
+	DepartsAt time.Time
+	Capacity  int
+	Active    bool
+	// Booked is the number of seats taken on the run
+	Booked int
+}
+
+// Label returns the run's label in the given language
+func (s ShuttleRun) Label(lang string) string {
+	if lang == "ro" {
+		return s.LabelRO
+	}
+	return s.LabelEN
+}
+
+// SeatsLeft returns the number of seats that can still be booked on the run
+func (s ShuttleRun) SeatsLeft() int {
+	if s.Booked >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.Booked
+}
+
+// queryShuttleRuns retrieves shuttle runs with their booked seats, in departure order
+func queryShuttleRuns(where string, args ...interface{}) ([]ShuttleRun, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, label_en, label_ro, departs_at, capacity, active,
+		       (SELECT COUNT(*) FROM shuttle_bookings WHERE run_id = shuttle_runs.id)
+		FROM shuttle_runs
+		`+where+`
+		ORDER BY departs_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []ShuttleRun
+
+	for rows.Next() {
+		var s ShuttleRun
+		if err := rows.Scan(&s.ID, &s.LabelEN, &s.LabelRO, &s.DepartsAt, &s.Capacity, &s.Active, &s.Booked); err != nil {
+			return nil, err
+		}
+		runs = append(runs, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// GetAllShuttleRuns retrieves every shuttle run, including retired ones
+func GetAllShuttleRuns() ([]ShuttleRun, error) {
+	return queryShuttleRuns("")
+}
+
+// GetActiveShuttleRuns retrieves the shuttle runs guests can currently book
+func GetActiveShuttleRuns() ([]ShuttleRun, error) {
+	return queryShuttleRuns("WHERE active")
+}
+
+// GetShuttleRun retrieves a single shuttle run
+func GetShuttleRun(id int64) (*ShuttleRun, error) {
+	runs, err := queryShuttleRuns("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("shuttle run not found")
+	}
+	return &runs[0], nil
+}
+
+// validateShuttleRun checks the fields of a shuttle run
+func validateShuttleRun(labelEN, labelRO string, capacity int) error {
+	if strings.TrimSpace(labelEN) == "" || strings.TrimSpace(labelRO) == "" {
+		return fmt.Errorf("labels are required in every language")
+	}
+	if capacity < 0 {
+		return fmt.Errorf("capacity cannot be negative")
+	}
+	return nil
+}
+
+// CreateShuttleRun adds a shuttle run guests can book
+func CreateShuttleRun(labelEN, labelRO string, departsAt time.Time, capacity int) error {
+	if err := validateShuttleRun(labelEN, labelRO, capacity); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO shuttle_runs (label_en, label_ro, departs_at, capacity, active)
+		VALUES (?, ?, ?, ?, TRUE)
+	`, strings.TrimSpace(labelEN), strings.TrimSpace(labelRO), departsAt, capacity)
+
+	return err
+}
+
+// UpdateShuttleRun changes a shuttle run. Runs are never deleted; retired runs keep their bookings.
+// Lowering the capacity below the booked seats keeps those bookings but stops new ones.
+func UpdateShuttleRun(id int64, labelEN, labelRO string, departsAt time.Time, capacity int, active bool) error {
+	if err := validateShuttleRun(labelEN, labelRO, capacity); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE shuttle_runs
+		SET label_en = ?, label_ro = ?, departs_at = ?, capacity = ?, active = ?
+		WHERE id = ?
+	`, strings.TrimSpace(labelEN), strings.TrimSpace(labelRO), departsAt, capacity, active, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("shuttle run not found")
+	}
+
+	return nil
+}
+
+// SetGuestShuttles replaces the shuttle runs a guest of the given invitation has a seat on.
+// Seats the guest already holds are kept; new seats are only taken while the run has room,
+// checked in the same statement that books them. It returns the runs that were full.
+// Guests who are not attending or are on the venue's waitlist give up all their seats.
+func SetGuestShuttles(guestID int64, email string, runIDs []int64) ([]int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Only allow updates for guests that belong to the given invitation
+	var eligible bool
+	err = tx.QueryRow(`
+		SELECT COALESCE(attending, FALSE) AND venue_status != ? FROM guests
+		WHERE id = ? AND invitation_email = ?
+	`, VenueWaitlisted, guestID, email).Scan(&eligible)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("guest not found or not authorized")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !eligible {
+		runIDs = nil
+	}
+
+	wanted := make(map[int64]bool, len(runIDs))
+	for _, id := range runIDs {
+		wanted[id] = true
+	}
+
+	booked, err := queryIDs(tx.Query(`
+		SELECT run_id FROM shuttle_bookings
+		WHERE guest_id = ?
+	`, guestID))
+	if err != nil {
+		return nil, err
+	}
+
+	held := make(map[int64]bool, len(booked))
+	for _, id := range booked {
+		held[id] = true
+		if wanted[id] {
+			continue
+		}
+		if _, err := tx.Exec(`
+			DELETE FROM shuttle_bookings
+			WHERE run_id = ? AND guest_id = ?
+		`, id, guestID); err != nil {
+			return nil, err
+		}
+	}
+
+	var full []int64
+	for _, id := range runIDs {
+		if held[id] {
+			continue
+		}
+
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO shuttle_bookings (run_id, guest_id)
+			SELECT id, ? FROM shuttle_runs
+			WHERE id = ? AND active
+			  AND capacity > (SELECT COUNT(*) FROM shuttle_bookings WHERE run_id = ?)
+		`, guestID, id, id)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if rows == 0 {
+			full = append(full, id)
+		}
+		held[id] = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return full, nil
+}
+
+// releaseShuttleSeats gives back the shuttle seats of guests who are not attending or are
+// waitlisted for the venue, so seats only go to guests who have a place
+func releaseShuttleSeats(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		DELETE FROM shuttle_bookings
+		WHERE guest_id IN (
+			SELECT id FROM guests
+			WHERE attending IS NULL OR attending = FALSE OR venue_status = ?
+		)
+	`, VenueWaitlisted)
+	return err
+}
+
+// attachShuttles fills in the shuttle runs the given guests have a seat on
+func attachShuttles(guests []Guest) error {
+	if len(guests) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(guests))
+	args := make([]interface{}, len(guests))
+	index := make(map[int64]int, len(guests))
+	for i, g := range guests {
+		placeholders[i] = "?"
+		args[i] = g.ID
+		index[g.ID] = i
+		guests[i].Shuttles = nil
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT b.guest_id, b.run_id FROM shuttle_bookings b
+		JOIN shuttle_runs s ON s.id = b.run_id
+		WHERE b.guest_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY s.departs_at, s.id
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guestID, runID int64
+		if err := rows.Scan(&guestID, &runID); err != nil {
+			return err
+		}
+		i := index[guestID]
+		guests[i].Shuttles = append(guests[i].Shuttles, runID)
+	}
+
+	return rows.Err()
+}
+
+// HasShuttle reports whether the guest has a seat on the given shuttle run
+func (g Guest) HasShuttle(runID int64) bool {
+	for _, id := range g.Shuttles {
+		if id == runID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetShuttleManifest retrieves a shuttle run and its passengers, grouped by invitation
+func GetShuttleManifest(runID int64) (*ShuttleRun, []Guest, error) {
+	run, err := GetShuttleRun(runID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	guests, err := GetAllGuests()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var passengers []Guest
+	for _, g := range guests {
+		if g.HasShuttle(runID) {
+			passengers = append(passengers, g)
+		}
+	}
+
+	return run, passengers, nil
+}
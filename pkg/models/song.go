@@ -0,0 +1,206 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Moderation statuses of a song request
+const (
+	SongPending   = "pending"
+	SongApproved  = "approved"
+	SongRejected  = "rejected"
+	SongMustPlay  = "must_play"
+	SongDoNotPlay = "do_not_play"
+)
+
+// SongStatuses lists the statuses admins can give a request
+var SongStatuses = []string{SongPending, SongApproved, SongMustPlay, SongRejected, SongDoNotPlay}
+
+// MaxSongRequests caps how many songs one invitation can request
+const MaxSongRequests = 10
+
+// ErrSongLimit is returned when the invitation already requested MaxSongRequests songs
+var ErrSongLimit = errors.New("song request limit reached")
+
+// SongRequest is a song an invitation asked the DJ to play
+type SongRequest struct {
+	ID              int64
+	InvitationEmail string
+	Title           string
+	Artist          string
+	Dedication      string
+	Status          string
+	CreatedAt       time.Time
+	Votes           int
+	// Voted reports whether the invitation the list was loaded for voted for the song
+	Voted bool
+}
+
+// IsHidden reports whether moderation took the song off the list guests see
+func (s SongRequest) IsHidden() bool {
+	return s.Status == SongRejected || s.Status == SongDoNotPlay
+}
+
+// RequestSong records a song request for the invitation. Asking for a song that was already
+// requested adds the invitation's vote to it instead of creating a duplicate.
+func RequestSong(email, title, artist, dedication string) error {
+	title = strings.TrimSpace(title)
+	artist = strings.TrimSpace(artist)
+	if title == "" || artist == "" {
+		return fmt.Errorf("title and artist are required")
+	}
+
+	var existingID int64
+	err := db.DB.QueryRow(`
+		SELECT id FROM song_requests
+		WHERE LOWER(title) = LOWER(?) AND LOWER(artist) = LOWER(?)
+	`, title, artist).Scan(&existingID)
+	if err == nil {
+		return VoteForSong(existingID, email, true)
+	}
+
+	var count int
+	if err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM song_requests WHERE invitation_email = ?
+	`, email).Scan(&count); err != nil {
+		return err
+	}
+	if count >= MaxSongRequests {
+		return ErrSongLimit
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO song_requests (invitation_email, title, artist, dedication)
+		VALUES (?, ?, ?, ?)
+	`, email, title, artist, strings.TrimSpace(dedication))
+
+	return err
+}
+
+// VoteForSong adds or removes the invitation's vote for a song guests can see.
+// Requesting a song already counts as the requester's vote, so they cannot vote for it.
+func VoteForSong(songID int64, email string, vote bool) error {
+	if !vote {
+		_, err := db.DB.Exec(`
+			DELETE FROM song_votes
+			WHERE song_id = ? AND invitation_email = ?
+		`, songID, email)
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT OR IGNORE INTO song_votes (song_id, invitation_email)
+		SELECT id, ? FROM song_requests
+		WHERE id = ? AND invitation_email != ? AND status NOT IN (?, ?)
+	`, email, songID, email, SongRejected, SongDoNotPlay)
+
+	return err
+}
+
+// DeleteSongRequest withdraws one of the invitation's own requests along with its votes
+func DeleteSongRequest(id int64, email string) error {
+	result, err := db.DB.Exec(`
+		DELETE FROM song_requests
+		WHERE id = ? AND invitation_email = ?
+	`, id, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("song request not found or not authorized")
+	}
+
+	_, err = db.DB.Exec(`DELETE FROM song_votes WHERE song_id = ?`, id)
+	return err
+}
+
+// SetSongStatus moderates a song request
+func SetSongStatus(id int64, status string) error {
+	valid := false
+	for _, s := range SongStatuses {
+		if s == status {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("unknown song status %q", status)
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE song_requests
+		SET status = ?
+		WHERE id = ?
+	`, status, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("song request not found")
+	}
+
+	return nil
+}
+
+// GetSongRequests returns every request, most voted first, marking the songs the given
+// invitation voted for. Each request counts its requester as one vote.
+func GetSongRequests(email string) ([]SongRequest, error) {
+	rows, err := db.DB.Query(`
+		SELECT s.id, s.invitation_email, s.title, s.artist, s.dedication, s.status, s.created_at,
+		       1 + (SELECT COUNT(*) FROM song_votes v WHERE v.song_id = s.id) AS votes,
+		       EXISTS (SELECT 1 FROM song_votes v WHERE v.song_id = s.id AND v.invitation_email = ?)
+		FROM song_requests s
+		ORDER BY votes DESC, s.created_at, s.id
+	`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []SongRequest
+	for rows.Next() {
+		var s SongRequest
+		if err := rows.Scan(&s.ID, &s.InvitationEmail, &s.Title, &s.Artist, &s.Dedication, &s.Status,
+			&s.CreatedAt, &s.Votes, &s.Voted); err != nil {
+			return nil, err
+		}
+		songs = append(songs, s)
+	}
+
+	return songs, rows.Err()
+}
+
+// GetPlaylist returns the songs for the DJ: must-play songs first, then approved songs,
+// each most voted first
+func GetPlaylist() ([]SongRequest, error) {
+	songs, err := GetSongRequests("")
+	if err != nil {
+		return nil, err
+	}
+
+	var mustPlay, approved []SongRequest
+	for _, song := range songs {
+		switch song.Status {
+		case SongMustPlay:
+			mustPlay = append(mustPlay, song)
+		case SongApproved:
+			approved = append(approved, song)
+		}
+	}
+
+	return append(mustPlay, approved...), nil
+}
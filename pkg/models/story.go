@@ -0,0 +1,156 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// StoryDateFormat is the layout of story entry dates, as sent by HTML date inputs
+const StoryDateFormat = "2006-01-02"
+
+// StoryEntry is a moment of the couple's "Our Story" timeline
+type StoryEntry struct {
+	ID         int64
+	HappenedOn time.Time
+	TitleEN    string
+	TitleRO    string
+	BodyEN     string
+	BodyRO     string
+	// PhotoID is the gallery photo shown with the entry, if any
+	PhotoID sql.NullInt64
+	Photo   *GalleryPhoto
+}
+
+// Title returns the entry's title in the given language
+func (e StoryEntry) Title(lang string) string {
+	if lang == "ro" {
+		return e.TitleRO
+	}
+	return e.TitleEN
+}
+
+// Body returns the entry's text in the given language
+func (e StoryEntry) Body(lang string) string {
+	if lang == "ro" {
+		return e.BodyRO
+	}
+	return e.BodyEN
+}
+
+// validateStoryEntry checks and parses the fields of a story entry
+func validateStoryEntry(happenedOn, titleEN, titleRO string, photoID int64) (time.Time, sql.NullInt64, error) {
+	date, err := time.Parse(StoryDateFormat, happenedOn)
+	if err != nil {
+		return time.Time{}, sql.NullInt64{}, fmt.Errorf("invalid date %q", happenedOn)
+	}
+	if strings.TrimSpace(titleEN) == "" || strings.TrimSpace(titleRO) == "" {
+		return time.Time{}, sql.NullInt64{}, fmt.Errorf("titles are required in both languages")
+	}
+
+	photo := sql.NullInt64{Int64: photoID, Valid: photoID > 0}
+	if photo.Valid {
+		if p, err := GetGalleryPhoto(photoID); err != nil {
+			return time.Time{}, sql.NullInt64{}, err
+		} else if p == nil {
+			return time.Time{}, sql.NullInt64{}, fmt.Errorf("photo not found")
+		}
+	}
+
+	return date, photo, nil
+}
+
+// CreateStoryEntry adds a moment to the timeline. A photoID of 0 means no photo.
+func CreateStoryEntry(happenedOn, titleEN, titleRO, bodyEN, bodyRO string, photoID int64) error {
+	date, photo, err := validateStoryEntry(happenedOn, titleEN, titleRO, photoID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO story_entries (happened_on, title_en, title_ro, body_en, body_ro, photo_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, date.Format(StoryDateFormat), strings.TrimSpace(titleEN), strings.TrimSpace(titleRO),
+		strings.TrimSpace(bodyEN), strings.TrimSpace(bodyRO), photo)
+
+	return err
+}
+
+// UpdateStoryEntry changes a moment of the timeline. A photoID of 0 removes its photo.
+func UpdateStoryEntry(id int64, happenedOn, titleEN, titleRO, bodyEN, bodyRO string, photoID int64) error {
+	date, photo, err := validateStoryEntry(happenedOn, titleEN, titleRO, photoID)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE story_entries
+		SET happened_on = ?, title_en = ?, title_ro = ?, body_en = ?, body_ro = ?, photo_id = ?
+		WHERE id = ?
+	`, date.Format(StoryDateFormat), strings.TrimSpace(titleEN), strings.TrimSpace(titleRO),
+		strings.TrimSpace(bodyEN), strings.TrimSpace(bodyRO), photo, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("story entry not found")
+	}
+
+	return nil
+}
+
+// DeleteStoryEntry removes a moment from the timeline; its photo stays in the gallery
+func DeleteStoryEntry(id int64) error {
+	_, err := db.DB.Exec(`DELETE FROM story_entries WHERE id = ?`, id)
+	return err
+}
+
+// GetStoryEntries returns the timeline in chronological order with each entry's photo
+func GetStoryEntries() ([]StoryEntry, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, happened_on, title_en, title_ro, body_en, body_ro, photo_id
+		FROM story_entries
+		ORDER BY happened_on, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []StoryEntry
+	for rows.Next() {
+		var e StoryEntry
+		var happenedOn string
+		if err := rows.Scan(&e.ID, &happenedOn, &e.TitleEN, &e.TitleRO, &e.BodyEN, &e.BodyRO, &e.PhotoID); err != nil {
+			return nil, err
+		}
+		if e.HappenedOn, err = time.Parse(StoryDateFormat, happenedOn); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	photos, err := queryGalleryPhotos("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		for j := range photos {
+			if entries[i].PhotoID.Valid && photos[j].ID == entries[i].PhotoID.Int64 {
+				entries[i].Photo = &photos[j]
+			}
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wedding-invite/pkg/db"
+	"wedding-invite/pkg/imaging"
+	"wedding-invite/pkg/maps"
+	"wedding-invite/pkg/storage"
+)
+
+// Kinds of travel place, which are also the sections of the travel page
+const (
+	TravelVenue     = "venue"
+	TravelHotel     = "hotel"
+	TravelAirport   = "airport"
+	TravelTransport = "transport"
+)
+
+// TravelKinds lists the kinds of place admins add to the travel page
+var TravelKinds = []string{TravelVenue, TravelAirport, TravelTransport}
+
+// TravelSections lists the sections of the travel page in order. The hotels section shows the
+// hotels with coordinates, which are managed with their room blocks.
+var TravelSections = []string{TravelVenue, TravelHotel, TravelAirport, TravelTransport}
+
+// Size of the travel page's static map, in pixels
+const (
+	TravelMapWidth  = 960
+	TravelMapHeight = 480
+)
+
+// TravelPlace is a venue, hotel, airport or transport stop shown on the travel page
+type TravelPlace struct {
+	ID        int64
+	Kind      string
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	// NotesEN and NotesRO are written in Markdown
+	NotesEN   string
+	NotesRO   string
+	Website   string
+	SortOrder int
+	// HotelID is set for hotels, which are edited with their room blocks
+	HotelID int64
+	// Number labels the place's marker on the static map
+	Number int
+}
+
+// Notes returns the place's Markdown notes in the given language
+func (p TravelPlace) Notes(lang string) string {
+	if lang == "ro" {
+		return p.NotesRO
+	}
+	return p.NotesEN
+}
+
+// OpenStreetMapURL links to the place on OpenStreetMap
+func (p TravelPlace) OpenStreetMapURL() string {
+	return maps.OpenStreetMapURL(p.Latitude, p.Longitude)
+}
+
+// GoogleMapsURL links to the place on Google Maps
+func (p TravelPlace) GoogleMapsURL() string {
+	return maps.GoogleMapsURL(p.Latitude, p.Longitude)
+}
+
+// AppleMapsURL links to the place on Apple Maps
+func (p TravelPlace) AppleMapsURL() string {
+	return maps.AppleMapsURL(p.Latitude, p.Longitude, p.Name)
+}
+
+// defaultTravelPlaces are the wedding venues and Bucharest's airport, seeded on first start
+var defaultTravelPlaces = []TravelPlace{
+	{Kind: TravelVenue, Name: "Biserica Icoanei", Address: "Str. Icoanei nr. 12, București", Latitude: 44.444290, Longitude: 26.104350},
+	{Kind: TravelVenue, Name: "Palatul Ghica Tei", Address: "Str. Doamna Ghica 3-5, București", Latitude: 44.460530, Longitude: 26.132390, SortOrder: 1},
+	{
+		Kind:      TravelAirport,
+		Name:      "Henri Coandă International Airport (OTP)",
+		Address:   "Calea Bucureștilor 224E, Otopeni",
+		Latitude:  44.571100,
+		Longitude: 26.085000,
+		NotesEN:   "About 17 km north of the city centre. Allow 30 to 60 minutes by taxi or ride-hailing, depending on traffic.",
+		NotesRO:   "La aproximativ 17 km nord de centrul orașului. Socotiți 30 până la 60 de minute cu taxiul sau cu o aplicație de transport, în funcție de trafic.",
+	},
+}
+
+// InitializeTravel seeds the venues and the airport if there are no travel places yet
+func InitializeTravel() error {
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM travel_places`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for _, p := range defaultTravelPlaces {
+		if err := CreateTravelPlace(p.Kind, p.Name, p.Address, p.Latitude, p.Longitude, p.NotesEN, p.NotesRO, p.Website, p.SortOrder); err != nil {
+			return fmt.Errorf("failed to seed travel place %s: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateTravelPlace checks the fields of a travel place
+func validateTravelPlace(kind, name string, latitude, longitude float64, website string) error {
+	valid := false
+	for _, k := range TravelKinds {
+		valid = valid || k == kind
+	}
+	if !valid {
+		return fmt.Errorf("unknown travel place kind %q", kind)
+	}
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if err := validateCoordinates(latitude, longitude); err != nil {
+		return err
+	}
+	return validateWebsite(website)
+}
+
+// validateCoordinates checks a latitude and longitude are on the map
+func validateCoordinates(latitude, longitude float64) error {
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 || math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return fmt.Errorf("coordinates are out of range")
+	}
+	return nil
+}
+
+// validateWebsite checks an optional website is a web address
+func validateWebsite(website string) error {
+	if website = strings.TrimSpace(website); website != "" {
+		u, err := url.Parse(website)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("website must be an http or https address")
+		}
+	}
+	return nil
+}
+
+// ParseCoordinate reads a latitude or longitude typed with either a decimal point or comma
+func ParseCoordinate(value string) (float64, error) {
+	coordinate, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(value), ",", ".", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", value)
+	}
+	return coordinate, nil
+}
+
+// ParseOptionalCoordinates reads a latitude and longitude that may both be left empty
+func ParseOptionalCoordinates(latitude, longitude string) (sql.NullFloat64, sql.NullFloat64, error) {
+	var lat, lon sql.NullFloat64
+	var err error
+	if strings.TrimSpace(latitude) != "" {
+		if lat.Float64, err = ParseCoordinate(latitude); err != nil {
+			return lat, lon, err
+		}
+		lat.Valid = true
+	}
+	if strings.TrimSpace(longitude) != "" {
+		if lon.Float64, err = ParseCoordinate(longitude); err != nil {
+			return lat, lon, err
+		}
+		lon.Valid = true
+	}
+	return lat, lon, nil
+}
+
+// GetTravelPlaces returns every travel place and every hotel with coordinates in page order,
+// numbered for the map
+func GetTravelPlaces() ([]TravelPlace, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, 0, kind, name, address, latitude, longitude, notes_en, notes_ro, website, sort_order
+		FROM travel_places
+		UNION ALL
+		SELECT 0, id, ?, name, address, latitude, longitude, notes_en, notes_ro, website, 0
+		FROM hotels
+		WHERE latitude IS NOT NULL AND longitude IS NOT NULL
+		ORDER BY sort_order, id, name
+	`, TravelHotel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var places []TravelPlace
+	for rows.Next() {
+		var p TravelPlace
+		if err := rows.Scan(&p.ID, &p.HotelID, &p.Kind, &p.Name, &p.Address, &p.Latitude, &p.Longitude,
+			&p.NotesEN, &p.NotesRO, &p.Website, &p.SortOrder); err != nil {
+			return nil, err
+		}
+		places = append(places, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	kindOrder := make(map[string]int, len(TravelSections))
+	for i, kind := range TravelSections {
+		kindOrder[kind] = i
+	}
+	sort.SliceStable(places, func(i, j int) bool {
+		return kindOrder[places[i].Kind] < kindOrder[places[j].Kind]
+	})
+	for i := range places {
+		places[i].Number = i + 1
+	}
+
+	return places, nil
+}
+
+// CreateTravelPlace adds a place to the travel page
+func CreateTravelPlace(kind, name, address string, latitude, longitude float64, notesEN, notesRO, website string, sortOrder int) error {
+	if err := validateTravelPlace(kind, name, latitude, longitude, website); err != nil {
+		return err
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO travel_places (kind, name, address, latitude, longitude, notes_en, notes_ro, website, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, kind, strings.TrimSpace(name), strings.TrimSpace(address), latitude, longitude,
+		strings.TrimSpace(notesEN), strings.TrimSpace(notesRO), strings.TrimSpace(website), sortOrder)
+
+	return err
+}
+
+// UpdateTravelPlace changes a place on the travel page
+func UpdateTravelPlace(id int64, kind, name, address string, latitude, longitude float64, notesEN, notesRO, website string, sortOrder int) error {
+	if err := validateTravelPlace(kind, name, latitude, longitude, website); err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(`
+		UPDATE travel_places
+		SET kind = ?, name = ?, address = ?, latitude = ?, longitude = ?, notes_en = ?, notes_ro = ?,
+		    website = ?, sort_order = ?
+		WHERE id = ?
+	`, kind, strings.TrimSpace(name), strings.TrimSpace(address), latitude, longitude,
+		strings.TrimSpace(notesEN), strings.TrimSpace(notesRO), strings.TrimSpace(website), sortOrder, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("travel place not found")
+	}
+
+	return nil
+}
+
+// DeleteTravelPlace removes a place from the travel page
+func DeleteTravelPlace(id int64) error {
+	_, err := db.DB.Exec(`DELETE FROM travel_places WHERE id = ?`, id)
+	return err
+}
+
+// travelMapRetry is how long a map drawn without all its tiles is reused before trying again
+const travelMapRetry = 10 * time.Minute
+
+var (
+	// travelMapMu keeps concurrent page loads from rendering, and fetching tiles for, the same map
+	travelMapMu sync.Mutex
+	// incompleteTravelMap holds the last map drawn without all its tiles, which is not stored
+	incompleteTravelMap struct {
+		key        string
+		data       []byte
+		renderedAt time.Time
+	}
+)
+
+// TravelMapKey names the static map of the given places in storage. It changes whenever a
+// marker moves or the tiles change, so an outdated map is never served.
+func TravelMapKey(places []TravelPlace) string {
+	hash := sha256.New()
+	tiles := ""
+	if source, ok := maps.Tiles.(maps.HTTPTiles); ok {
+		tiles = source.URLTemplate
+	}
+	fmt.Fprintf(hash, "%s %d %d\n", tiles, TravelMapWidth, TravelMapHeight)
+	for _, p := range places {
+		fmt.Fprintf(hash, "%d %.6f %.6f\n", p.Number, p.Latitude, p.Longitude)
+	}
+	return "travel-maps/" + hex.EncodeToString(hash.Sum(nil))[:32] + ".jpg"
+}
+
+// GetTravelMap returns the JPEG static map of the places stored under key, rendering and
+// storing it on first use. A map drawn while tiles could not be loaded is kept in memory only,
+// so it is rendered again later.
+func GetTravelMap(places []TravelPlace, key string) ([]byte, error) {
+	travelMapMu.Lock()
+	defer travelMapMu.Unlock()
+
+	if file, err := storage.Uploads.Open(key); err == nil {
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	if incompleteTravelMap.key == key && time.Since(incompleteTravelMap.renderedAt) < travelMapRetry {
+		return incompleteTravelMap.data, nil
+	}
+
+	markers := make([]maps.Marker, 0, len(places))
+	for _, p := range places {
+		markers = append(markers, maps.Marker{Lat: p.Latitude, Lon: p.Longitude, Number: p.Number})
+	}
+
+	img, complete, err := maps.Render(markers, TravelMapWidth, TravelMapHeight, maps.Tiles)
+	if err != nil {
+		return nil, err
+	}
+	data, err := imaging.EncodeJPEG(img, 85)
+	if err != nil {
+		return nil, err
+	}
+
+	if !complete {
+		log.Printf("Travel map rendered without all its tiles, will retry in %s", travelMapRetry)
+		incompleteTravelMap.key, incompleteTravelMap.data, incompleteTravelMap.renderedAt = key, data, time.Now()
+		return data, nil
+	}
+	if err := storage.Uploads.Save(key, bytes.NewReader(data)); err != nil {
+		// Serve the map anyway; it is rendered again next time
+		log.Printf("Error storing travel map: %v", err)
+	}
+
+	return data, nil
+}
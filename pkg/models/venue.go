@@ -0,0 +1,226 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+// Venue statuses of an attending guest
+const (
+	VenueConfirmed  = "confirmed"
+	VenueWaitlisted = "waitlisted"
+)
+
+const venueCapacitySetting = "venue_capacity"
+
+// VenueOverview summarises how the venue's places are taken
+type VenueOverview struct {
+	// Capacity is the venue's headcount limit, 0 when there is none
+	Capacity  int
+	Confirmed int
+	// Waitlist holds the waitlisted guests in the order they will be promoted
+	Waitlist []Guest
+	// Promoted holds the guests that moved off the waitlist, most recent first
+	Promoted []Guest
+}
+
+// IsWaitlisted reports whether the guest accepted but is waiting for a place at the venue
+func (g Guest) IsWaitlisted() bool {
+	return g.Attending.Valid && g.Attending.Bool && g.VenueStatus == VenueWaitlisted
+}
+
+// GetVenueCapacity returns the venue's headcount limit, or 0 when there is none
+func GetVenueCapacity() (int, error) {
+	value, err := getSetting(venueCapacitySetting, "0")
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(value)
+}
+
+// SetVenueCapacity sets the venue's headcount limit; 0 removes the limit
+func SetVenueCapacity(capacity int) error {
+	if capacity < 0 {
+		return fmt.Errorf("capacity cannot be negative")
+	}
+
+	return setSetting(venueCapacitySetting, strconv.Itoa(capacity))
+}
+
+// AllocateVenuePlaces gives places at the venue to attending guests. Places freed by guests
+// who declined go to the waitlist first, in the order guests were waitlisted; guests who
+// newly accepted then get the remaining places, in submission order, or join the waitlist.
+// Waitlisted guests and guests who declined give up their shuttle seats.
+// It returns the guests promoted from the waitlist.
+func AllocateVenuePlaces() ([]Guest, error) {
+	capacity, err := GetVenueCapacity()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Write first, so the transaction holds SQLite's write lock before counting places
+	// and concurrent submissions cannot both take the last one
+	_, err = tx.Exec(`
+		UPDATE guests
+		SET venue_status = '', waitlisted_at = NULL
+		WHERE venue_status != '' AND (attending IS NULL OR attending = FALSE)
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var confirmed int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM guests
+		WHERE attending = TRUE AND venue_status = ?
+	`, VenueConfirmed).Scan(&confirmed); err != nil {
+		return nil, err
+	}
+
+	hasPlace := func() bool {
+		return capacity == 0 || confirmed < capacity
+	}
+
+	now := time.Now()
+
+	// Promote waitlisted guests into places freed since the last allocation
+	waitlisted, err := queryIDs(tx.Query(`
+		SELECT id FROM guests
+		WHERE attending = TRUE AND venue_status = ?
+		ORDER BY waitlisted_at, id
+	`, VenueWaitlisted))
+	if err != nil {
+		return nil, err
+	}
+
+	var promotedIDs []int64
+	for _, id := range waitlisted {
+		if !hasPlace() {
+			break
+		}
+		if _, err := tx.Exec(`
+			UPDATE guests
+			SET venue_status = ?, waitlisted_at = NULL, promoted_at = ?
+			WHERE id = ?
+		`, VenueConfirmed, now, id); err != nil {
+			return nil, err
+		}
+		confirmed++
+		promotedIDs = append(promotedIDs, id)
+	}
+
+	// Place guests who accepted since the last allocation
+	unplaced, err := queryIDs(tx.Query(`
+		SELECT id FROM guests
+		WHERE attending = TRUE AND venue_status = ''
+		ORDER BY last_updated, id
+	`))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range unplaced {
+		if hasPlace() {
+			_, err = tx.Exec(`
+				UPDATE guests
+				SET venue_status = ?
+				WHERE id = ?
+			`, VenueConfirmed, id)
+			confirmed++
+		} else {
+			_, err = tx.Exec(`
+				UPDATE guests
+				SET venue_status = ?, waitlisted_at = ?
+				WHERE id = ?
+			`, VenueWaitlisted, now, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Guests who lost their place or declined no longer need a shuttle seat
+	if err := releaseShuttleSeats(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	promoted := make([]Guest, 0, len(promotedIDs))
+	for _, id := range promotedIDs {
+		guest, err := GetGuest(id)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, *guest)
+	}
+
+	return promoted, nil
+}
+
+// queryIDs collects the IDs returned by a query
+func queryIDs(rows *sql.Rows, err error) ([]int64, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetVenueOverview retrieves the venue's capacity, its taken places, the waitlist and past promotions
+func GetVenueOverview() (*VenueOverview, error) {
+	capacity, err := GetVenueCapacity()
+	if err != nil {
+		return nil, err
+	}
+
+	guests, err := GetAllGuests()
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &VenueOverview{Capacity: capacity}
+	for _, g := range guests {
+		if g.Attending.Valid && g.Attending.Bool && g.VenueStatus == VenueConfirmed {
+			overview.Confirmed++
+		}
+		if g.IsWaitlisted() {
+			overview.Waitlist = append(overview.Waitlist, g)
+		}
+		if g.PromotedAt.Valid {
+			overview.Promoted = append(overview.Promoted, g)
+		}
+	}
+
+	sort.SliceStable(overview.Waitlist, func(i, j int) bool {
+		return overview.Waitlist[i].WaitlistedAt.Time.Before(overview.Waitlist[j].WaitlistedAt.Time)
+	})
+	sort.SliceStable(overview.Promoted, func(i, j int) bool {
+		return overview.Promoted[i].PromotedAt.Time.After(overview.Promoted[j].PromotedAt.Time)
+	})
+
+	return overview, nil
+}
@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"wedding-invite/pkg/db"
+)
+
+func TestAllocateVenuePlaces(t *testing.T) {
+	accepted := sql.NullBool{Bool: true, Valid: true}
+	declined := sql.NullBool{Bool: false, Valid: true}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		// answers changes the guests' attendance, by name, before allocating
+		answers  map[string]sql.NullBool
+		promoted []string
+		statuses map[string]string
+	}
+
+	for _, tc := range []struct {
+		name     string
+		capacity int
+		// guests accept in this order
+		guests []string
+		steps  []step
+	}{
+		{"no limit", 0, []string{"Ana", "Bogdan", "Cristina"}, []step{{
+			statuses: map[string]string{"Ana": VenueConfirmed, "Bogdan": VenueConfirmed, "Cristina": VenueConfirmed},
+		}}},
+		{"places go in submission order", 2, []string{"Ana", "Bogdan", "Cristina", "Dan"}, []step{{
+			statuses: map[string]string{"Ana": VenueConfirmed, "Bogdan": VenueConfirmed, "Cristina": VenueWaitlisted, "Dan": VenueWaitlisted},
+		}}},
+		{"a freed place goes to the first on the waitlist", 2, []string{"Ana", "Bogdan", "Cristina", "Dan"}, []step{
+			{},
+			{
+				answers:  map[string]sql.NullBool{"Ana": declined},
+				promoted: []string{"Cristina"},
+				statuses: map[string]string{"Ana": "", "Bogdan": VenueConfirmed, "Cristina": VenueConfirmed, "Dan": VenueWaitlisted},
+			},
+		}},
+		{"the waitlist comes before new acceptances", 2, []string{"Ana", "Bogdan", "Cristina"}, []step{
+			{},
+			{
+				answers:  map[string]sql.NullBool{"Bogdan": declined, "Elena": accepted},
+				promoted: []string{"Cristina"},
+				statuses: map[string]string{"Bogdan": "", "Cristina": VenueConfirmed, "Elena": VenueWaitlisted},
+			},
+		}},
+		{"withdrawing from the waitlist", 1, []string{"Ana", "Bogdan", "Cristina"}, []step{
+			{},
+			{
+				answers:  map[string]sql.NullBool{"Bogdan": {}},
+				statuses: map[string]string{"Ana": VenueConfirmed, "Bogdan": "", "Cristina": VenueWaitlisted},
+			},
+			{
+				answers:  map[string]sql.NullBool{"Ana": declined},
+				promoted: []string{"Cristina"},
+				statuses: map[string]string{"Ana": "", "Bogdan": "", "Cristina": VenueConfirmed},
+			},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTestDB(t)
+			createTestInvitation(t, "family@example.com")
+			if err := SetVenueCapacity(tc.capacity); err != nil {
+				t.Fatal(err)
+			}
+
+			ids := make(map[string]int64)
+			updated := start
+			for _, name := range tc.guests {
+				updated = updated.Add(time.Minute)
+				ids[name] = createTestGuest(t, "family@example.com", name, accepted, updated)
+			}
+
+			for i, s := range tc.steps {
+				for name, answer := range s.answers {
+					updated = updated.Add(time.Minute)
+					if _, ok := ids[name]; !ok {
+						ids[name] = createTestGuest(t, "family@example.com", name, answer, updated)
+						continue
+					}
+					if _, err := db.DB.Exec(`UPDATE guests SET attending = ?, last_updated = ? WHERE id = ?`,
+						answer, updated, ids[name]); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				promoted, err := AllocateVenuePlaces()
+				if err != nil {
+					t.Fatalf("step %d: AllocateVenuePlaces: %v", i, err)
+				}
+
+				var promotedNames []string
+				for _, g := range promoted {
+					promotedNames = append(promotedNames, g.Name)
+				}
+				if len(promotedNames) != len(s.promoted) {
+					t.Errorf("step %d: promoted %v, want %v", i, promotedNames, s.promoted)
+				}
+				for j := range promotedNames {
+					if j < len(s.promoted) && promotedNames[j] != s.promoted[j] {
+						t.Errorf("step %d: promoted %v, want %v", i, promotedNames, s.promoted)
+						break
+					}
+				}
+
+				for name, want := range s.statuses {
+					guest, err := GetGuest(ids[name])
+					if err != nil {
+						t.Fatal(err)
+					}
+					if guest.VenueStatus != want {
+						t.Errorf("step %d: %s is %q, want %q", i, name, guest.VenueStatus, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVenueOverviewWaitlistOrder(t *testing.T) {
+	setupTestDB(t)
+	createTestInvitation(t, "family@example.com")
+	if err := SetVenueCapacity(1); err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := sql.NullBool{Bool: true, Valid: true}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Ana", "Bogdan", "Cristina"} {
+		createTestGuest(t, "family@example.com", name, accepted, start.Add(time.Duration(i)*time.Minute))
+		if _, err := AllocateVenuePlaces(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overview, err := GetVenueOverview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Capacity != 1 || overview.Confirmed != 1 {
+		t.Errorf("capacity %d with %d confirmed, want 1 with 1", overview.Capacity, overview.Confirmed)
+	}
+	if len(overview.Waitlist) != 2 || overview.Waitlist[0].Name != "Bogdan" || overview.Waitlist[1].Name != "Cristina" {
+		t.Errorf("waitlist = %v, want Bogdan then Cristina", overview.Waitlist)
+	}
+
+	if err := SetVenueCapacity(-1); err == nil {
+		t.Error("SetVenueCapacity accepted a negative capacity")
+	}
+}
+
+func TestWaitlistedGuestsHoldNoShuttleSeats(t *testing.T) {
+	setupTestDB(t)
+	createTestInvitation(t, "family@example.com")
+	if err := SetVenueCapacity(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateShuttleRun("Church to palace", "Biserică - palat", time.Now().Add(24*time.Hour), 10); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := GetActiveShuttleRuns()
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected one shuttle run, got %v (%v)", runs, err)
+	}
+	runID := runs[0].ID
+
+	accepted := sql.NullBool{Bool: true, Valid: true}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ana := createTestGuest(t, "family@example.com", "Ana", accepted, start)
+	bogdan := createTestGuest(t, "family@example.com", "Bogdan", accepted, start.Add(time.Minute))
+
+	// Both book before the RSVP is allocated, as the RSVP form does
+	for _, id := range []int64{ana, bogdan} {
+		if _, err := SetGuestShuttles(id, "family@example.com", []int64{runID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := AllocateVenuePlaces(); err != nil {
+		t.Fatal(err)
+	}
+
+	seats := func(id int64) []int64 {
+		t.Helper()
+		guest, err := GetGuest(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return guest.Shuttles
+	}
+	if got := seats(ana); len(got) != 1 {
+		t.Errorf("Ana has a place but holds shuttle seats %v", got)
+	}
+	if got := seats(bogdan); len(got) != 0 {
+		t.Errorf("waitlisted Bogdan still holds shuttle seats %v", got)
+	}
+
+	// A waitlisted guest cannot book again
+	if _, err := SetGuestShuttles(bogdan, "family@example.com", []int64{runID}); err != nil {
+		t.Fatal(err)
+	}
+	if got := seats(bogdan); len(got) != 0 {
+		t.Errorf("waitlisted Bogdan booked shuttle seats %v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package printing
+
+import (
+	"io"
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/models"
+)
+
+// Guestbook writes the keepsake book of guestbook messages: a title page, then the
+// messages in the order they were written, each signed with its author and date
+func Guestbook(w io.Writer, entries []models.GuestbookEntry, lang string) error {
+	pdf := newDocument("P")
+	pdf.SetMargins(25, 25, 25)
+	pdf.SetAutoPageBreak(true, 25)
+
+	pdf.AddPage()
+	pdf.SetY(110)
+	pdf.SetFont(fontFamily, "B", 32)
+	pdf.CellFormat(0, 16, i18n.T(lang, "print.guestbook.title"), "", 1, "C", false, 0, "")
+	pdf.SetFont(fontFamily, "", 14)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, 10, i18n.T(lang, "print.guestbook.subtitle"), "", 1, "C", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	pdf.AddPage()
+	if len(entries) == 0 {
+		pdf.SetFont(fontFamily, "", 12)
+		pdf.CellFormat(0, 10, i18n.T(lang, "print.guestbook.empty"), "", 1, "L", false, 0, "")
+	}
+
+	for i, entry := range entries {
+		// Keep a short message and its signature together on one page
+		pdf.SetFont(fontFamily, "", 12)
+		lines := len(pdf.SplitText(entry.Message, 160))
+		if lines <= 12 && pdf.GetY()+float64(lines)*6+14 > 297-25 {
+			pdf.AddPage()
+		}
+		if i > 0 && pdf.GetY() > 25 {
+			pdf.SetDrawColor(200, 200, 200)
+			pdf.Line(85, pdf.GetY(), 125, pdf.GetY())
+			pdf.Ln(6)
+		}
+
+		pdf.MultiCell(0, 6, entry.Message, "", "L", false)
+		pdf.Ln(2)
+		pdf.SetFont(fontFamily, "B", 11)
+		pdf.CellFormat(0, 6, "— "+entry.AuthorName, "", 1, "R", false, 0, "")
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 5, i18n.FormatDate(lang, entry.CreatedAt), "", 1, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(6)
+	}
+
+	return pdf.Output(w)
+}
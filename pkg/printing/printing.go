@@ -0,0 +1,131 @@
+// Package printing renders print-ready PDFs for the wedding day. Text is set in DejaVu Sans
+// Condensed, loaded from static/fonts, so names keep their Romanian diacritics.
+//
+// PDFs are drawn with go-pdf/fpdf, the continuation of the archived jung-kurt/gofpdf with the
+// same API. It is pure Go with no dependencies, so the server needs no headless browser or cgo
+// PDF library, and it embeds UTF-8 TrueType fonts.
+package printing
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"wedding-invite/pkg/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const fontFamily = "DejaVu"
+
+var fontDir = filepath.Join("static", "fonts")
+
+// newDocument starts an A4 document in millimetres with the DejaVu fonts registered
+func newDocument(orientation string) *fpdf.Fpdf {
+	pdf := fpdf.New(orientation, "mm", "A4", "")
+	pdf.AddUTF8Font(fontFamily, "", filepath.Join(fontDir, "DejaVuSansCondensed.ttf"))
+	pdf.AddUTF8Font(fontFamily, "B", filepath.Join(fontDir, "DejaVuSansCondensed-Bold.ttf"))
+	pdf.SetFont(fontFamily, "", 10)
+	return pdf
+}
+
+// fitFont sets the font at size, shrinking it down to 6pt until text fits in width
+func fitFont(pdf *fpdf.Fpdf, style string, size float64, text string, width float64) {
+	pdf.SetFont(fontFamily, style, size)
+	for size > 6 && pdf.GetStringWidth(text) > width {
+		size--
+		pdf.SetFont(fontFamily, style, size)
+	}
+}
+
+// mealIcon is how a meal option is marked on printed material: a coloured disc with a short code
+type mealIcon struct {
+	Code  string
+	Color [3]int
+}
+
+// mealPalette holds colours that stay distinct from each other when printed
+var mealPalette = [][3]int{
+	{31, 119, 180},
+	{44, 160, 44},
+	{214, 39, 40},
+	{255, 127, 14},
+	{148, 103, 189},
+	{140, 86, 75},
+	{227, 119, 194},
+	{23, 190, 207},
+	{188, 189, 34},
+	{127, 127, 127},
+}
+
+// mealIcons assigns each meal option an icon. Codes are the capitals of the option's English
+// label, numbered when options would share one, so "Gluten Free" becomes "GF". Generated keys
+// carry no capitals, so the label is used rather than the key.
+func mealIcons(options []models.MealOption) map[string]mealIcon {
+	icons := make(map[string]mealIcon, len(options))
+	used := make(map[string]int)
+	for i, option := range options {
+		var code []rune
+		for _, word := range strings.FieldsFunc(option.LabelEN, func(r rune) bool { return r == ' ' || r == '-' }) {
+			first := []rune(word)[0]
+			if unicode.IsUpper(first) && len(code) < 3 {
+				code = append(code, first)
+			}
+		}
+		if len(code) == 0 {
+			if label := []rune(option.LabelEN); len(label) > 0 {
+				code = []rune{unicode.ToUpper(label[0])}
+			} else {
+				code = []rune{'?'}
+			}
+		}
+
+		key := string(code)
+		used[key]++
+		if n := used[key]; n > 1 {
+			key += strconv.Itoa(n)
+		}
+
+		icons[option.Key] = mealIcon{Code: key, Color: mealPalette[i%len(mealPalette)]}
+	}
+	return icons
+}
+
+// drawMealIcon draws the icon as a disc of the given radius centred on x, y
+func drawMealIcon(pdf *fpdf.Fpdf, icon mealIcon, x, y, radius float64) {
+	pdf.SetFillColor(icon.Color[0], icon.Color[1], icon.Color[2])
+	pdf.Circle(x, y, radius, "F")
+
+	pdf.SetTextColor(255, 255, 255)
+	fitFont(pdf, "B", radius*3.4, icon.Code, radius*1.7)
+	pdf.SetXY(x-radius, y-radius)
+	pdf.CellFormat(radius*2, radius*2, icon.Code, "", 0, "CM", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// drawMealLegend lists the icons of the given options from x, y, wrapping at maxX. It returns
+// the y position below the legend.
+func drawMealLegend(pdf *fpdf.Fpdf, options []models.MealOption, icons map[string]mealIcon, lang string, x, y, maxX float64) float64 {
+	startX := x
+	for _, option := range options {
+		icon, ok := icons[option.Key]
+		if !ok {
+			continue
+		}
+
+		label := models.MealLabel(lang, option.Key)
+		pdf.SetFont(fontFamily, "", 7)
+		width := pdf.GetStringWidth(label) + 10
+		if x+width > maxX && x > startX {
+			x = startX
+			y += 5
+		}
+
+		drawMealIcon(pdf, icon, x+2, y+2, 2)
+		pdf.SetFont(fontFamily, "", 7)
+		pdf.SetXY(x+5, y)
+		pdf.CellFormat(width-5, 4, label, "", 0, "LM", false, 0, "")
+		x += width
+	}
+	return y + 5
+}
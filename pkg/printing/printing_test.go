@@ -0,0 +1,31 @@
+package printing
+
+import (
+	"testing"
+	"wedding-invite/pkg/models"
+)
+
+func TestMealIcons(t *testing.T) {
+	options := []models.MealOption{
+		{Key: "Gluten-Free", LabelEN: "Gluten Free"},
+		{Key: "Standard", LabelEN: "Standard"},
+		{Key: "starter-1a2b3c4d", LabelEN: "starter"},
+		{Key: "sarmale-5e6f7a8b", LabelEN: "șarmale"},
+		{Key: "fish-menu-9c0d1e2f", LabelEN: "Smoked Fish"},
+		{Key: "empty", LabelEN: ""},
+	}
+
+	icons := mealIcons(options)
+	for key, want := range map[string]string{
+		"Gluten-Free":        "GF",
+		"Standard":           "S",
+		"starter-1a2b3c4d":   "S2",
+		"sarmale-5e6f7a8b":   "Ș",
+		"fish-menu-9c0d1e2f": "SF",
+		"empty":              "?",
+	} {
+		if got := icons[key].Code; got != want {
+			t.Errorf("icon of %q = %q, want %q", key, got, want)
+		}
+	}
+}
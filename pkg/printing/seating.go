@@ -0,0 +1,335 @@
+package printing
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"wedding-invite/pkg/i18n"
+	"wedding-invite/pkg/models"
+)
+
+// Place cards are cut from a 2 by 5 grid on each A4 page
+const (
+	cardWidth   = 95.0
+	cardHeight  = 54.0
+	cardColumns = 2
+	cardRows    = 5
+	cardMarginX = (210 - cardColumns*cardWidth) / 2
+	cardMarginY = (297 - cardRows*cardHeight) / 2
+)
+
+// message looks up a translation and fills in its {0}, {1}, ... placeholders
+func message(lang, key string, args ...string) string {
+	msg := i18n.T(lang, key)
+	for i, arg := range args {
+		msg = strings.Replace(msg, "{"+strconv.Itoa(i)+"}", arg, -1)
+	}
+	return msg
+}
+
+// tableLabel names a table by its number in the plan, adding its name when that says more
+func tableLabel(lang string, number int, table models.SeatingTable) string {
+	label := message(lang, "print.table", strconv.Itoa(number))
+	if table.Name != strconv.Itoa(number) {
+		label += " · " + table.Name
+	}
+	return label
+}
+
+// PlaceCards writes a place card for every seated guest, table by table in seat order.
+// Each card shows the guest's name, their table and an icon for their meal.
+func PlaceCards(w io.Writer, plan *models.SeatingPlan, meals []models.MealOption, lang string) error {
+	pdf := newDocument("P")
+	pdf.SetAutoPageBreak(false, 0)
+	icons := mealIcons(meals)
+
+	card := 0
+	for i, table := range plan.Tables {
+		label := tableLabel(lang, i+1, table)
+		for _, guest := range table.Guests {
+			position := card % (cardColumns * cardRows)
+			if position == 0 {
+				pdf.AddPage()
+				drawMealLegend(pdf, meals, icons, lang, cardMarginX, 297-cardMarginY+2, 210-cardMarginX)
+			}
+			card++
+
+			x := cardMarginX + float64(position%cardColumns)*cardWidth
+			y := cardMarginY + float64(position/cardColumns)*cardHeight
+
+			// Dashed cutting guide
+			pdf.SetDrawColor(180, 180, 180)
+			pdf.SetLineWidth(0.2)
+			pdf.SetDashPattern([]float64{1, 1}, 0)
+			pdf.Rect(x, y, cardWidth, cardHeight, "D")
+			pdf.SetDashPattern([]float64{}, 0)
+
+			fitFont(pdf, "B", 22, guest.Name, cardWidth-12)
+			pdf.SetXY(x+6, y+14)
+			pdf.CellFormat(cardWidth-12, 14, guest.Name, "", 0, "CM", false, 0, "")
+
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.SetTextColor(100, 100, 100)
+			pdf.SetXY(x+6, y+30)
+			pdf.CellFormat(cardWidth-12, 6, label, "", 0, "CM", false, 0, "")
+			pdf.SetTextColor(0, 0, 0)
+
+			if icon, ok := icons[guest.MealPreference.String]; ok && guest.MealPreference.Valid {
+				drawMealIcon(pdf, icon, x+cardWidth-9, y+cardHeight-9, 4.5)
+			}
+		}
+	}
+
+	if card == 0 {
+		pdf.AddPage()
+		pdf.SetFont(fontFamily, "", 12)
+		pdf.CellFormat(0, 10, i18n.T(lang, "print.nobody_seated"), "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// escortEntry is a line of the escort card list
+type escortEntry struct {
+	Name   string
+	Number int
+	Table  string
+}
+
+// EscortList writes the alphabetical list of seated guests with their table numbers
+func EscortList(w io.Writer, plan *models.SeatingPlan, lang string) error {
+	pdf := newDocument("P")
+	pdf.SetAutoPageBreak(false, 0)
+
+	var entries []escortEntry
+	for i, table := range plan.Tables {
+		for _, guest := range table.Guests {
+			entries = append(entries, escortEntry{Name: guest.Name, Number: i + 1, Table: table.Name})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	header := func() {
+		pdf.AddPage()
+		pdf.SetFont(fontFamily, "B", 20)
+		pdf.CellFormat(0, 14, i18n.T(lang, "print.escort.title"), "", 1, "C", false, 0, "")
+		pdf.Ln(2)
+		pdf.SetFont(fontFamily, "B", 11)
+		pdf.SetFillColor(235, 235, 235)
+		pdf.CellFormat(110, 8, i18n.T(lang, "print.escort.guest"), "B", 0, "L", true, 0, "")
+		pdf.CellFormat(25, 8, i18n.T(lang, "print.escort.number"), "B", 0, "C", true, 0, "")
+		pdf.CellFormat(55, 8, i18n.T(lang, "print.escort.table"), "B", 1, "L", true, 0, "")
+	}
+
+	header()
+	for _, entry := range entries {
+		if pdf.GetY() > 297-20 {
+			header()
+		}
+		pdf.SetFont(fontFamily, "", 11)
+		pdf.CellFormat(110, 7, entry.Name, "B", 0, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "B", 11)
+		pdf.CellFormat(25, 7, strconv.Itoa(entry.Number), "B", 0, "C", false, 0, "")
+		pdf.SetFont(fontFamily, "", 11)
+		pdf.CellFormat(55, 7, entry.Table, "B", 1, "L", false, 0, "")
+	}
+
+	if len(entries) == 0 {
+		pdf.SetFont(fontFamily, "", 12)
+		pdf.CellFormat(0, 10, i18n.T(lang, "print.nobody_seated"), "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// TableSheets writes a page per table for the waiting staff: who sits where with their meal,
+// allergens and dietary notes, followed by the table's meal and allergen counts.
+func TableSheets(w io.Writer, plan *models.SeatingPlan, meals []models.MealOption, lang string) error {
+	pdf := newDocument("P")
+	pdf.SetAutoPageBreak(false, 0)
+	icons := mealIcons(meals)
+
+	// Column widths: seat, guest, meal, allergens, notes
+	widths := []float64{12, 50, 45, 45, 38}
+	columnHeader := func() {
+		pdf.SetFont(fontFamily, "B", 9)
+		pdf.SetFillColor(235, 235, 235)
+		titles := []string{"seat", "guest", "meal", "allergens", "notes"}
+		for i, title := range titles {
+			pdf.CellFormat(widths[i], 7, i18n.T(lang, "print.sheet."+title), "B", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	for i, table := range plan.Tables {
+		pdf.AddPage()
+		pdf.SetFont(fontFamily, "B", 18)
+		pdf.CellFormat(0, 10, tableLabel(lang, i+1, table), "", 1, "L", false, 0, "")
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(0, 6, message(lang, "print.sheet.occupancy",
+			strconv.Itoa(len(table.Guests)), strconv.Itoa(table.Capacity)), "", 1, "L", false, 0, "")
+		if len(table.HighChairs) > 0 {
+			pdf.CellFormat(0, 6, message(lang, "print.sheet.high_chairs",
+				strconv.Itoa(len(table.HighChairs))), "", 1, "L", false, 0, "")
+		}
+		pdf.Ln(3)
+		columnHeader()
+
+		// Infants are served at the table too, on high chairs, which have no seat number
+		rows := append([]models.SeatedGuest(nil), table.Guests...)
+		for _, infant := range table.HighChairs {
+			rows = append(rows, models.SeatedGuest{Guest: infant})
+		}
+
+		mealCounts := make(map[string]int)
+		allergenCounts := make(map[string]int)
+		for _, guest := range rows {
+			meal := ""
+			if guest.MealPreference.Valid && guest.MealPreference.String != "" {
+				meal = guest.MealPreference.String
+				mealCounts[meal]++
+			}
+			allergenNames := make([]string, 0, len(guest.Allergens))
+			for _, allergen := range guest.Allergens {
+				allergenCounts[allergen]++
+				allergenNames = append(allergenNames, i18n.T(lang, "allergens."+allergen))
+			}
+			notes := ""
+			if guest.DietaryRestrictions.Valid {
+				notes = guest.DietaryRestrictions.String
+			}
+
+			mealLabel := ""
+			if meal != "" {
+				mealLabel = models.MealLabel(lang, meal)
+			}
+			seat := strconv.Itoa(guest.Seat)
+			if guest.Seat == 0 {
+				seat = i18n.T(lang, "print.sheet.high_chair")
+			}
+			cells := []string{seat, guest.Name, mealLabel, strings.Join(allergenNames, ", "), notes}
+
+			// Wrap long cells and size the row to the tallest one
+			pdf.SetFont(fontFamily, "", 9)
+			lines := 1
+			for c, cell := range cells {
+				width := widths[c] - 2
+				if c == 2 {
+					width -= 6
+				}
+				if n := len(pdf.SplitText(cell, width)); n > lines {
+					lines = n
+				}
+			}
+			height := float64(lines)*4.5 + 2
+			if pdf.GetY()+height > 297-15 {
+				pdf.AddPage()
+				columnHeader()
+			}
+
+			x, y := pdf.GetXY()
+			for c, cell := range cells {
+				left := x
+				for _, width := range widths[:c] {
+					left += width
+				}
+				width := widths[c]
+				if c == 2 && meal != "" {
+					if icon, ok := icons[meal]; ok {
+						drawMealIcon(pdf, icon, left+3, y+3.25, 2.25)
+						pdf.SetFont(fontFamily, "", 9)
+					}
+					left += 6
+					width -= 6
+				}
+				pdf.SetXY(left, y+1)
+				pdf.MultiCell(width, 4.5, cell, "", "L", false)
+			}
+			pdf.SetDrawColor(200, 200, 200)
+			pdf.Line(x, y+height, x+sumWidths(widths), y+height)
+			pdf.SetXY(x, y+height)
+		}
+
+		// Totals for the kitchen and the waiters
+		if pdf.GetY() > 297-60 {
+			pdf.AddPage()
+		}
+		pdf.Ln(6)
+		pdf.SetFont(fontFamily, "B", 12)
+		pdf.CellFormat(0, 7, i18n.T(lang, "print.sheet.meal_counts"), "", 1, "L", false, 0, "")
+		if len(mealCounts) == 0 {
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.CellFormat(0, 6, i18n.T(lang, "print.sheet.none"), "", 1, "L", false, 0, "")
+		}
+		for _, option := range countedMeals(mealCounts, meals) {
+			x, y := pdf.GetXY()
+			if icon, ok := icons[option]; ok {
+				drawMealIcon(pdf, icon, x+2.5, y+3, 2.25)
+			}
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.SetXY(x+7, y)
+			pdf.CellFormat(80, 6, models.MealLabel(lang, option), "", 0, "L", false, 0, "")
+			pdf.SetFont(fontFamily, "B", 10)
+			pdf.CellFormat(15, 6, strconv.Itoa(mealCounts[option]), "", 1, "R", false, 0, "")
+		}
+
+		pdf.Ln(4)
+		pdf.SetFont(fontFamily, "B", 12)
+		pdf.CellFormat(0, 7, i18n.T(lang, "print.sheet.allergen_counts"), "", 1, "L", false, 0, "")
+		if len(allergenCounts) == 0 {
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.CellFormat(0, 6, i18n.T(lang, "print.sheet.none"), "", 1, "L", false, 0, "")
+		}
+		for _, allergen := range models.Allergens {
+			if allergenCounts[allergen] == 0 {
+				continue
+			}
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.CellFormat(87, 6, i18n.T(lang, "allergens."+allergen), "", 0, "L", false, 0, "")
+			pdf.SetFont(fontFamily, "B", 10)
+			pdf.CellFormat(15, 6, strconv.Itoa(allergenCounts[allergen]), "", 1, "R", false, 0, "")
+		}
+	}
+
+	if len(plan.Tables) == 0 {
+		pdf.AddPage()
+		pdf.SetFont(fontFamily, "", 12)
+		pdf.CellFormat(0, 10, i18n.T(lang, "print.nobody_seated"), "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// countedMeals lists the counted meal keys in menu order, followed by any no longer on the menu
+func countedMeals(counts map[string]int, options []models.MealOption) []string {
+	keys := make([]string, 0, len(counts))
+	listed := make(map[string]bool, len(options))
+	for _, option := range options {
+		listed[option.Key] = true
+		if counts[option.Key] > 0 {
+			keys = append(keys, option.Key)
+		}
+	}
+
+	var others []string
+	for key := range counts {
+		if !listed[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+
+	return append(keys, others...)
+}
+
+// sumWidths adds up column widths
+func sumWidths(widths []float64) float64 {
+	total := 0.0
+	for _, width := range widths {
+		total += width
+	}
+	return total
+}
@@ -0,0 +1,119 @@
+// Package storage keeps uploaded files. Files are addressed by slash-separated keys such as
+// "guest-photos/3f2a.jpg", so the backing store can change without touching the database.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage saves, reads and deletes files by key
+type Storage interface {
+	// Save stores the contents of r under key, replacing any file already there
+	Save(key string, r io.Reader) error
+	// Open returns the file stored under key
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key; deleting a missing file is not an error
+	Delete(key string) error
+}
+
+// Uploads is where files uploaded by guests and admins are kept
+var Uploads Storage
+
+// Initialize sets up Uploads on local disk, in UPLOADS_DIR or else an "uploads" directory
+// next to the database, which on fly.io is the mounted volume
+func Initialize() error {
+	dir := os.Getenv("UPLOADS_DIR")
+	if dir == "" {
+		dbPath := os.Getenv("DB_PATH")
+		if dbPath == "" {
+			dbPath = "wedding.db"
+		}
+		dir = filepath.Join(filepath.Dir(dbPath), "uploads")
+	}
+
+	log.Printf("Storing uploads in: %s", dir)
+
+	disk, err := NewLocalDisk(dir)
+	if err != nil {
+		return err
+	}
+
+	Uploads = disk
+	return nil
+}
+
+// LocalDisk stores files in a directory of the local filesystem
+type LocalDisk struct {
+	root string
+}
+
+// NewLocalDisk returns a LocalDisk rooted at dir, creating the directory if needed
+func NewLocalDisk(dir string) (*LocalDisk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+	}
+	return &LocalDisk{root: dir}, nil
+}
+
+// path maps a key to a file below the root, refusing keys that would escape it
+func (d *LocalDisk) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Save writes the file to a temporary name first, so readers never see a partial file
+func (d *LocalDisk) Save(key string, r io.Reader) error {
+	target, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// Open opens the file stored under key
+func (d *LocalDisk) Open(key string) (io.ReadCloser, error) {
+	target, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(target)
+}
+
+// Delete removes the file stored under key
+func (d *LocalDisk) Delete(key string) error {
+	target, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}